@@ -10,6 +10,7 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
@@ -25,7 +26,6 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/resource"
 	"github.com/tedacmc/tedac/tedac"
 	"github.com/tedacmc/tedac/tedac/chunk"
-	"github.com/tedacmc/tedac/tedac/latestmappings"
 	"github.com/tedacmc/tedac/tedac/legacyprotocol/legacypacket"
 	"github.com/wailsapp/wails/lib/renderer/webview"
 	"golang.org/x/oauth2"
@@ -40,12 +40,19 @@ type App struct {
 	src oauth2.TokenSource
 	ctx context.Context
 
+	// downloadMu guards download and conns.
+	downloadMu sync.Mutex
+	// download is the world download that chunks sent to players are written to, if the world is being downloaded.
+	download *tedac.WorldDownload
+	// conns holds the connections of all players currently using Tedac.
+	conns map[*minecraft.Conn]struct{}
+
 	c chan interface{}
 }
 
 // NewApp creates a new App application struct.
 func NewApp() *App {
-	return &App{src: tokenSource(), c: make(chan interface{})}
+	return &App{src: tokenSource(), conns: make(map[*minecraft.Conn]struct{}), c: make(chan interface{})}
 }
 
 // ProxyInfo ...
@@ -179,11 +186,89 @@ func (a *App) CheckNetIsolation() bool {
 // startup is called when the app starts. The context is saved, so we can call the runtime methods.
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	if err := a.ReloadFallbacks(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// ReloadFallbacks reads the block and item fallbacks from the fallbacks.json file next to the executable again.
+// Chunks and items sent to the client after reloading use the new fallbacks.
+func (a *App) ReloadFallbacks() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("find fallbacks: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("find fallbacks: %w", err)
+	}
+	return tedac.LoadFallbacks(filepath.Join(filepath.Dir(exe), "fallbacks.json"))
+}
+
+// StartWorldDownload starts writing the chunks and block entities that players are sent to a new world in the worlds
+// directory. If legacy is true, the world is written in the format of v1.12.0, so that it may be opened by the
+// v1.12.0 client. Otherwise, it is written in the format of the latest version. The path of the world is returned.
+func (a *App) StartWorldDownload(legacy bool) (string, error) {
+	a.downloadMu.Lock()
+	defer a.downloadMu.Unlock()
+	if a.download != nil {
+		return "", errors.New("world is already being downloaded")
+	}
+
+	dir := filepath.Join("worlds", time.Now().Format("2006-01-02_15-04-05"))
+	newWorldDownload := tedac.NewWorldDownload
+	if legacy {
+		newWorldDownload = tedac.NewLegacyWorldDownload
+	}
+	d, err := newWorldDownload(dir)
+	if err != nil {
+		return "", err
+	}
+	a.download = d
+	for conn := range a.conns {
+		tedac.DownloadWorld(conn, d)
+	}
+	return dir, nil
+}
+
+// StopWorldDownload stops writing chunks to the world started using StartWorldDownload and saves the world.
+func (a *App) StopWorldDownload() error {
+	a.downloadMu.Lock()
+	defer a.downloadMu.Unlock()
+	if a.download == nil {
+		return errors.New("world is not being downloaded")
+	}
+	for conn := range a.conns {
+		tedac.DownloadWorld(conn, nil)
+	}
+	err := a.download.Close()
+	a.download = nil
+	return err
+}
+
+// trackConn starts downloading the world for the connection passed if a world download is active, and keeps track of
+// the connection until it is closed, so that world downloads started later on include it.
+func (a *App) trackConn(conn *minecraft.Conn) {
+	a.downloadMu.Lock()
+	defer a.downloadMu.Unlock()
+	a.conns[conn] = struct{}{}
+	if a.download != nil {
+		tedac.DownloadWorld(conn, a.download)
+	}
+	go func() {
+		<-conn.Context().Done()
+		a.downloadMu.Lock()
+		defer a.downloadMu.Unlock()
+		delete(a.conns, conn)
+	}()
+}
+
+// SetYOffset sets the amount of blocks that the world is shifted upwards for players that join afterwards, so that
+// blocks below Y 0 or above Y 255 become visible. The offset must be a multiple of 16 between -64 and 64.
+func (a *App) SetYOffset(offset int32) error {
+	return tedac.SetYOffset(offset)
 }
 
 var (
-	// airRID is the runtime ID of the air block in the latest version of the game.
-	airRID, _ = latestmappings.StateToRuntimeID("minecraft:air", nil)
 	// defaultSkinResourcePatch holds the skin resource patch assigned to a player when they wear a custom skin.
 	defaultSkinResourcePatch = base64.StdEncoding.EncodeToString([]byte(`
 		{
@@ -242,6 +327,10 @@ func (a *App) handleConn(conn *minecraft.Conn) {
 	}()
 	g.Wait()
 
+	if _, ok := conn.Protocol().(tedac.Protocol); ok {
+		a.trackConn(conn)
+	}
+
 	// TODO: Component-ize the shit below.
 	rid := data.EntityRuntimeID
 
@@ -298,7 +387,7 @@ func (a *App) handleConn(conn *minecraft.Conn) {
 				inputs.Set(packet.InputFlagJumping)
 			}
 
-			if err = serverConn.WritePacket(&packet.PlayerAuthInput{
+			input := &packet.PlayerAuthInput{
 				Delta:            currentPos.Sub(originalPos),
 				HeadYaw:          currentYaw,
 				InputData:        inputs,
@@ -309,7 +398,9 @@ func (a *App) handleConn(conn *minecraft.Conn) {
 				Position:         currentPos,
 				Tick:             tick,
 				Yaw:              currentYaw,
-			}); err != nil {
+			}
+			tedac.VehicleInput(conn, input)
+			if err = serverConn.WritePacket(input); err != nil {
 				return
 			}
 			_ = serverConn.Flush()
@@ -410,6 +501,7 @@ func (a *App) handleConn(conn *minecraft.Conn) {
 
 				chunkBuf := bytes.NewBuffer(nil)
 				blockEntities := make([]map[string]any, 0)
+				airRID := tedac.LatestAirRuntimeID(conn)
 				for _, entry := range pk.SubChunkEntries {
 					if entry.Result != protocol.SubChunkResultSuccess {
 						chunkBuf.Write([]byte{
@@ -428,9 +520,9 @@ func (a *App) handleConn(conn *minecraft.Conn) {
 						continue
 					}
 
-					var blockEntity map[string]any
 					dec := nbt.NewDecoderWithEncoding(readBuf, nbt.NetworkLittleEndian)
 					for {
+						var blockEntity map[string]any
 						if err := dec.Decode(&blockEntity); err != nil {
 							break
 						}
@@ -444,6 +536,7 @@ func (a *App) handleConn(conn *minecraft.Conn) {
 				_, _ = chunkBuf.Write(append(biomeBufferCache[chunkPos], 0))
 				delete(biomeBufferCache, chunkPos)
 
+				// The block entities are downgraded by the protocol along with the rest of the chunk.
 				enc := nbt.NewEncoderWithEncoding(chunkBuf, nbt.NetworkLittleEndian)
 				for _, b := range blockEntities {
 					_ = enc.Encode(b)
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/df-mc/worldupgrader/itemupgrader"
+)
+
+// maxLegacyMeta is the highest metadata value that is checked for every legacy item when looking for items that
+// were flattened into an item per metadata value.
+const maxLegacyMeta = 1024
+
+// metaFallbacks holds the metadata values of legacy items that were only added after v1.12.0, mapped to the
+// metadata value that v1.12.0 shows in their place. Metadata values mapped to -1 have no equivalent in v1.12.0, so
+// the items they were flattened into fall back like any other unknown item.
+var metaFallbacks = map[string]map[int16]int16{
+	// Black, brown, blue and white dyes were split off from ink sacs, cocoa beans, lapis lazuli and bone meal.
+	"minecraft:dye": {16: 0, 17: 3, 18: 4, 19: 15},
+	// Buckets of powder snow, axolotls and tadpoles.
+	"minecraft:bucket": {11: 0, 12: 8, 13: 8},
+	// Mangrove, bamboo, cherry and pale oak boats.
+	"minecraft:boat": {6: 0, 7: 0, 8: 0, 9: 5},
+	// Piglin heads.
+	"minecraft:skull": {6: 2},
+	// Piglin and globe banner patterns.
+	"minecraft:banner_pattern": {6: -1, 7: -1},
+}
+
+// damageableSuffixes and damageableItems decide which items store their damage in the metadata value in v1.12.0.
+// Items are matched by their name in the latest version.
+var (
+	damageableSuffixes = []string{
+		"_sword", "_shovel", "_pickaxe", "_axe", "_hoe", "_helmet", "_chestplate", "_leggings", "_boots",
+	}
+	damageableItems = map[string]struct{}{
+		"minecraft:bow": {}, "minecraft:crossbow": {}, "minecraft:trident": {}, "minecraft:shield": {},
+		"minecraft:fishing_rod": {}, "minecraft:carrot_on_a_stick": {}, "minecraft:flint_and_steel": {},
+		"minecraft:shears": {}, "minecraft:elytra": {},
+	}
+)
+
+// flattenedItem is an entry of the item metadata table, as embedded in the legacy mappings.
+type flattenedItem struct {
+	Name       string `json:"name"`
+	LegacyName string `json:"legacy_name"`
+	Meta       int16  `json:"meta"`
+}
+
+// itemTables holds the item tables of the legacy mappings that are generated from the v1.12.0 item IDs and the
+// vanilla items of the latest version.
+type itemTables struct {
+	// aliases maps the name of an item in the latest version to the name it had in v1.12.0.
+	aliases map[string]string
+	// flattened holds the items of the latest version that were a metadata value of a v1.12.0 item.
+	flattened []flattenedItem
+	// damageable holds the v1.12.0 names of the items that store their damage in the metadata value.
+	damageable []string
+}
+
+// generateItemTables generates the item tables of the legacy mappings. legacyIDs holds the IDs of all v1.12.0
+// items, legacyEntities the IDs of all v1.12.0 entities and vanilla the names of all items in the latest version.
+// Items are upgraded from v1.12.0 to the latest version using the item upgrade schemas of the game.
+func generateItemTables(legacyIDs map[string]int16, legacyEntities map[string]int32, vanilla map[string]struct{}) itemTables {
+	names := make([]string, 0, len(legacyIDs))
+	for name := range legacyIDs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if legacyIDs[names[i]] != legacyIDs[names[j]] {
+			return legacyIDs[names[i]] < legacyIDs[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	entityIDs := make(map[int16]struct{}, len(legacyEntities))
+	for _, id := range legacyEntities {
+		entityIDs[int16(id)] = struct{}{}
+	}
+
+	t := itemTables{aliases: make(map[string]string)}
+	for _, legacyName := range names {
+		// No upgrade schema remaps the wildcard metadata value, so only the renames of the item are applied.
+		name := itemupgrader.Upgrade(itemupgrader.ItemMeta{Name: legacyName, Meta: 0x7fff}).Name
+		if _, ok := vanilla[name]; ok && name != legacyName {
+			t.aliases[name] = legacyName
+		}
+		if damageable(name) {
+			t.damageable = append(t.damageable, legacyName)
+		}
+
+		for meta := int16(0); meta < maxLegacyMeta; meta++ {
+			upgraded := itemupgrader.Upgrade(itemupgrader.ItemMeta{Name: legacyName, Meta: meta})
+			if upgraded.Name == name || upgraded.Name == legacyName {
+				continue
+			}
+			if _, ok := vanilla[upgraded.Name]; !ok {
+				continue
+			}
+			legacyMeta := meta
+			if fallback, ok := metaFallbacks[legacyName][meta]; ok {
+				if fallback < 0 {
+					continue
+				}
+				legacyMeta = fallback
+			}
+			if _, ok := entityIDs[meta]; legacyName == "minecraft:spawn_egg" && !ok {
+				// Spawn eggs of entities added after v1.12.0 have no equivalent.
+				continue
+			}
+			t.flattened = append(t.flattened, flattenedItem{Name: upgraded.Name, LegacyName: legacyName, Meta: legacyMeta})
+		}
+	}
+	return t
+}
+
+// damageable checks if the item with the name passed stores its damage in the metadata value in v1.12.0.
+func damageable(name string) bool {
+	if _, ok := damageableItems[name]; ok {
+		return true
+	}
+	for _, suffix := range damageableSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeAliases encodes the item alias table as embedded in the legacy mappings.
+func (t itemTables) encodeAliases() ([]byte, error) {
+	data, err := json.MarshalIndent(t.aliases, "", "  ")
+	return append(data, '\n'), err
+}
+
+// encodeMeta encodes the item metadata table as embedded in the legacy mappings, with one entry on every line.
+func (t itemTables) encodeMeta() ([]byte, error) {
+	buf := bytes.NewBufferString("{\n  \"flattened\": [\n")
+	for i, f := range t.flattened {
+		data, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("    ")
+		buf.Write(bytes.ReplaceAll(bytes.ReplaceAll(data, []byte(`":`), []byte(`": `)), []byte(`,"`), []byte(`, "`)))
+		if i != len(t.flattened)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	damageable, err := json.Marshal(t.damageable)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(buf, "  ],\n  \"damageable\": %s\n}\n", bytes.ReplaceAll(damageable, []byte(`","`), []byte(`", "`)))
+	return buf.Bytes(), nil
+}
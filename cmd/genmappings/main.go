@@ -0,0 +1,232 @@
+// Command genmappings regenerates the block and item mappings embedded in Tedac for a new release of the game. It
+// takes the vanilla block palette and item table of the new release, upgrades the v1.12.0 block state map to the
+// new release, generates the item alias and metadata tables and writes all embedded files. Legacy block states that
+// no longer map to a state in the new palette are reported, in which case nothing is written unless -allow-lost is
+// passed, so that they can be fixed before the new mappings are used.
+//
+// Usage:
+//
+//	go run ./cmd/genmappings -version 1.21.90 -blocks canonical_block_states.nbt -items required_item_list.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+)
+
+// legacyMapPattern is the pattern that the name of the v1.12.0 block state map matches. The version it was upgraded
+// to is part of the name.
+const legacyMapPattern = "1.12.0_to_*_blockstate_map.bin"
+
+func main() {
+	version := flag.String("version", "", "version of the new release, such as 1.21.90")
+	blocks := flag.String("blocks", "", "path to the vanilla block palette of the new release (canonical_block_states.nbt)")
+	items := flag.String("items", "", "path to the vanilla item table of the new release (required_item_list.json)")
+	legacy := flag.String("legacy", "", "path to the v1.12.0 block state map (default: the one in -legacy-out)")
+	latestOut := flag.String("latest-out", "tedac/latestmappings", "directory to write the latest mappings to")
+	legacyOut := flag.String("legacy-out", "tedac/legacymappings", "directory to write the legacy mappings to")
+	allowLost := flag.Bool("allow-lost", false, "write the mappings even if legacy block states no longer map to a state")
+	flag.Parse()
+
+	if *version == "" || *blocks == "" || *items == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *legacy == "" {
+		matches, _ := filepath.Glob(filepath.Join(*legacyOut, legacyMapPattern))
+		if len(matches) != 1 {
+			log.Fatalf("expected a single v1.12.0 block state map in %v, found %v", *legacyOut, len(matches))
+		}
+		*legacy = matches[0]
+	}
+
+	paletteData, err := os.ReadFile(*blocks)
+	if err != nil {
+		log.Fatalf("read block palette: %v", err)
+	}
+	palette, err := readPalette(paletteData)
+	if err != nil {
+		log.Fatalf("decode block palette: %v", err)
+	}
+	itemData, vanillaItems, err := readItems(*items)
+	if err != nil {
+		log.Fatalf("read item table: %v", err)
+	}
+	legacyData, err := os.ReadFile(*legacy)
+	if err != nil {
+		log.Fatalf("read legacy block state map: %v", err)
+	}
+	upgraded, lost := upgradeLegacyMap(legacyData, palette)
+
+	var legacyIDs map[string]int16
+	if err := readJSON(filepath.Join(*legacyOut, "item_id_map.json"), &legacyIDs); err != nil {
+		log.Fatalf("read legacy item IDs: %v", err)
+	}
+	var legacyEntities struct {
+		Legacy map[string]int32 `json:"legacy"`
+	}
+	if err := readJSON(filepath.Join(*legacyOut, "entity_map.json"), &legacyEntities); err != nil {
+		log.Fatalf("read legacy entities: %v", err)
+	}
+	tables := generateItemTables(legacyIDs, legacyEntities.Legacy, vanillaItems)
+	aliasData, err := tables.encodeAliases()
+	if err != nil {
+		log.Fatalf("encode item aliases: %v", err)
+	}
+	metaData, err := tables.encodeMeta()
+	if err != nil {
+		log.Fatalf("encode item metadata: %v", err)
+	}
+
+	if len(lost) > 0 {
+		sort.Strings(lost)
+		log.Printf("%v legacy block states no longer map to a state in the new palette:", len(lost))
+		for _, l := range lost {
+			fmt.Println(l)
+		}
+		if !*allowLost {
+			log.Fatalf("no mappings were written, pass -allow-lost to write them anyway")
+		}
+	}
+
+	legacyMapPath := filepath.Join(*legacyOut, "1.12.0_to_"+*version+"_blockstate_map.bin")
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{filepath.Join(*latestOut, "block_states.nbt"), paletteData},
+		{filepath.Join(*latestOut, "vanilla_items.nbt"), itemData},
+		{legacyMapPath, upgraded},
+		{filepath.Join(*legacyOut, "item_alias_map.json"), aliasData},
+		{filepath.Join(*legacyOut, "item_meta_map.json"), metaData},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(f.path, f.data, 0644); err != nil {
+			log.Fatalf("write %v: %v", f.path, err)
+		}
+	}
+	// The legacy mappings embed a single block state map, so the map of the previous release is removed.
+	if filepath.Clean(*legacy) != filepath.Clean(legacyMapPath) && filepath.Dir(filepath.Clean(*legacy)) == filepath.Clean(*legacyOut) {
+		if err := os.Remove(*legacy); err != nil {
+			log.Fatalf("remove previous legacy block state map: %v", err)
+		}
+	}
+	log.Printf("wrote %v block states, %v item aliases, %v flattened items and the v1.12.0 block state map for %v", len(palette), len(tables.aliases), len(tables.flattened), *version)
+}
+
+// readJSON decodes the JSON file at the path passed into the value passed.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// readPalette decodes all block states in a block palette and returns a set of their hashes.
+func readPalette(data []byte) (map[latestmappings.StateHash]struct{}, error) {
+	palette := make(map[latestmappings.StateHash]struct{})
+	dec := nbt.NewDecoder(bytes.NewBuffer(data))
+	for {
+		var s blockupgrader.BlockState
+		if err := dec.Decode(&s); err != nil {
+			break
+		}
+		palette[latestmappings.HashState(s)] = struct{}{}
+	}
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("palette holds no block states")
+	}
+	return palette, nil
+}
+
+// vanillaItem is an entry of the vanilla item table, as it is embedded in the latest mappings.
+type vanillaItem struct {
+	RuntimeID      int32          `json:"runtime_id" nbt:"runtime_id"`
+	ComponentBased bool           `json:"component_based" nbt:"component_based"`
+	Version        int32          `json:"version" nbt:"version"`
+	Data           map[string]any `json:"data,omitempty" nbt:"data,omitempty"`
+}
+
+// readItems reads the item table at the path passed and returns it encoded as it is embedded in the latest
+// mappings, along with the names of all items in it. Both item tables in JSON and in NBT are supported.
+func readItems(path string) ([]byte, map[string]struct{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	items := make(map[string]vanillaItem)
+	if filepath.Ext(path) == ".nbt" {
+		err = nbt.Unmarshal(data, &items)
+	} else {
+		err = json.Unmarshal(data, &items)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("item table holds no items")
+	}
+	names := make(map[string]struct{}, len(items))
+	for name := range items {
+		names[name] = struct{}{}
+	}
+	encoded, err := nbt.Marshal(items)
+	return encoded, names, err
+}
+
+// upgradeLegacyMap upgrades every block state in the v1.12.0 block state map to the latest version, and returns
+// the encoded map along with a description of every state that is not present in the palette passed.
+func upgradeLegacyMap(data []byte, palette map[latestmappings.StateHash]struct{}) ([]byte, []string) {
+	r := protocol.NewReader(bytes.NewBuffer(data), 0, false)
+	buf := bytes.NewBuffer(nil)
+	w := protocol.NewWriter(buf, 0)
+
+	var lost []string
+	var length uint32
+	r.Varuint32(&length)
+	w.Varuint32(&length)
+	for i := uint32(0); i < length; i++ {
+		var legacyStringID string
+		r.String(&legacyStringID)
+		w.String(&legacyStringID)
+
+		var pairs uint32
+		r.Varuint32(&pairs)
+		w.Varuint32(&pairs)
+		for y := uint32(0); y < pairs; y++ {
+			var meta uint32
+			r.Varuint32(&meta)
+			w.Varuint32(&meta)
+
+			var raw map[string]any
+			r.NBT(&raw, nbt.LittleEndian)
+			state := blockupgrader.Upgrade(blockupgrader.BlockState{
+				Name:       raw["name"].(string),
+				Properties: raw["states"].(map[string]any),
+				Version:    raw["version"].(int32),
+			})
+			if _, ok := palette[latestmappings.HashState(state)]; !ok {
+				lost = append(lost, fmt.Sprintf("%v:%v -> %v %v", legacyStringID, meta, state.Name, state.Properties))
+			}
+			if state.Properties == nil {
+				state.Properties = map[string]any{}
+			}
+			upgraded := map[string]any{"name": state.Name, "states": state.Properties, "version": state.Version}
+			w.NBT(&upgraded, nbt.LittleEndian)
+		}
+	}
+	return buf.Bytes(), lost
+}
@@ -0,0 +1,77 @@
+// Command mappingcoverage reports how complete the translation of blocks and items of the latest version to
+// v1.12.0 is. It lists the block states and items that have no v1.12.0 equivalent, that are shown as a fallback
+// configured by the user, and that are not translated back to the same block state or item.
+//
+// Usage:
+//
+//	go run ./cmd/mappingcoverage -format markdown -fallbacks fallbacks.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/tedacmc/tedac/tedac"
+)
+
+func main() {
+	format := flag.String("format", "json", "output format, either json or markdown")
+	fallbacks := flag.String("fallbacks", "", "path to a fallbacks file to take into account")
+	flag.Parse()
+
+	if *fallbacks != "" {
+		if err := tedac.LoadFallbacks(*fallbacks); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	coverage := tedac.MappingCoverage()
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(coverage); err != nil {
+			log.Fatalln(err)
+		}
+	case "markdown":
+		writeMarkdown(os.Stdout, coverage)
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+}
+
+// writeMarkdown writes the coverage passed as a Markdown report.
+func writeMarkdown(w io.Writer, c tedac.Coverage) {
+	fmt.Fprintln(w, "# Mapping coverage")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Kind | Total | Unmapped | Fallback | Lossy |")
+	fmt.Fprintln(w, "|------|------:|---------:|---------:|------:|")
+	writeSummary(w, "Block states", c.Blocks)
+	writeSummary(w, "Items", c.Items)
+
+	writeEntries(w, "Unmapped block states", c.Blocks.Unmapped)
+	writeEntries(w, "Block states shown as fallback", c.Blocks.Fallback)
+	writeEntries(w, "Lossy block states", c.Blocks.Lossy)
+	writeEntries(w, "Unmapped items", c.Items.Unmapped)
+	writeEntries(w, "Items shown as fallback", c.Items.Fallback)
+	writeEntries(w, "Lossy items", c.Items.Lossy)
+}
+
+// writeSummary writes a row of the summary table for the entries passed.
+func writeSummary(w io.Writer, kind string, e tedac.CoverageEntries) {
+	fmt.Fprintf(w, "| %v | %v | %v | %v | %v |\n", kind, e.Total, len(e.Unmapped), len(e.Fallback), len(e.Lossy))
+}
+
+// writeEntries writes a section listing the entries passed. Nothing is written if there are no entries.
+func writeEntries(w io.Writer, title string, entries []string) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n## %v\n\n", title)
+	fmt.Fprintln(w, "- `"+strings.Join(entries, "`\n- `")+"`")
+}
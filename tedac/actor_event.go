@@ -0,0 +1,67 @@
+package tedac
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol/legacypacket"
+)
+
+// animateActionNone is the animate action that plays no animation at all.
+const animateActionNone = 0
+
+// animateActions maps the animate actions of the latest version to the action played in v1.12.0.
+var animateActions = map[int32]int32{
+	packet.AnimateActionSwingArm: packet.AnimateActionSwingArm,
+	// Waking up from a bed has the same ID in both versions.
+	packet.AnimateActionStopSleep:   packet.AnimateActionStopSleep,
+	packet.AnimateActionCriticalHit: packet.AnimateActionCriticalHit,
+	// v1.12.0 has no separate particles for hits with enchanted weapons.
+	packet.AnimateActionMagicCriticalHit: packet.AnimateActionCriticalHit,
+	// Both rowing actions existed in v1.12.0 already and carry the rowing time the same way.
+	packet.AnimateActionRowRight: packet.AnimateActionRowRight,
+	packet.AnimateActionRowLeft:  packet.AnimateActionRowLeft,
+}
+
+// downgradeAnimate downgrades an Animate packet of the latest version to v1.12.0. Actions unknown to v1.12.0 are
+// shown as a swing of the arm, which every entity that may be animated can play. False is only returned for the
+// action that plays no animation, as there is nothing to show for it.
+func downgradeAnimate(pk *packet.Animate) (*legacypacket.Animate, bool) {
+	if pk.ActionType == animateActionNone {
+		return nil, false
+	}
+	action, ok := animateActions[int32(pk.ActionType)]
+	if !ok {
+		action = packet.AnimateActionSwingArm
+	}
+	return &legacypacket.Animate{
+		ActionType:      action,
+		EntityRuntimeID: pk.EntityRuntimeID,
+		BoatRowingTime:  pk.Data,
+	}, true
+}
+
+// downgradeActorEvent downgrades an actor event of the latest version to v1.12.0. Actor events added after v1.12.0
+// are replaced with a similar actor event, and false is returned if there is none. Item data of the actor event is
+// downgraded as well.
+func (s *session) downgradeActorEvent(pk *packet.ActorEvent) (*packet.ActorEvent, bool) {
+	eventType, ok := legacymappings.DowngradeActorEvent(pk.EventType)
+	if !ok {
+		return nil, false
+	}
+	eventData := pk.EventData
+	if feed, _ := legacymappings.ActorEventID("feed"); eventType == feed {
+		// The eating particles hold the network ID of the item in the upper bits of the data, and the metadata value
+		// in the lower bits.
+		item := s.downgradeItem(protocol.ItemStack{ItemType: protocol.ItemType{
+			NetworkID:     eventData >> 16,
+			MetadataValue: uint32(eventData & 0xffff),
+		}})
+		eventData = item.NetworkID<<16 | int32(uint16(item.MetadataValue))
+	}
+	return &packet.ActorEvent{
+		EntityRuntimeID: pk.EntityRuntimeID,
+		EventType:       eventType,
+		EventData:       eventData,
+	}, true
+}
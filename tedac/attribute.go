@@ -0,0 +1,117 @@
+package tedac
+
+import (
+	"math"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol/legacypacket"
+)
+
+// attributeBounds holds the lowest minimum and the highest maximum that v1.12.0 accepts for an attribute.
+type attributeBounds struct {
+	min, max float32
+}
+
+// legacyAttributes holds the bounds of every attribute that exists in v1.12.0, indexed by the name of the
+// attribute. Attributes that are not in the map are not sent to the client.
+var legacyAttributes = map[string]attributeBounds{
+	"minecraft:absorption":                  {0, math.MaxFloat32},
+	"minecraft:player.saturation":           {0, 20},
+	"minecraft:player.exhaustion":           {0, 5},
+	"minecraft:knockback_resistance":        {0, 1},
+	"minecraft:health":                      {0, math.MaxFloat32},
+	"minecraft:movement":                    {0, math.MaxFloat32},
+	"minecraft:follow_range":                {0, 2048},
+	"minecraft:player.hunger":               {0, 20},
+	"minecraft:attack_damage":               {0, math.MaxFloat32},
+	"minecraft:player.level":                {0, 24791},
+	"minecraft:player.experience":           {0, 1},
+	"minecraft:luck":                        {-1024, 1024},
+	"minecraft:fall_damage":                 {0, math.MaxFloat32},
+	"minecraft:horse.jump_strength":         {0, 2},
+	"minecraft:zombie.spawn_reinforcements": {0, 1},
+}
+
+// downgradeAttribute downgrades an attribute of the latest version to v1.12.0, clamping its values to the bounds
+// v1.12.0 accepts. False is returned if the attribute does not exist in v1.12.0.
+func downgradeAttribute(a protocol.Attribute) (legacyprotocol.Attribute, bool) {
+	b, ok := legacyAttributes[a.Name]
+	if !ok {
+		return legacyprotocol.Attribute{}, false
+	}
+	minimum, maximum := b.clamp(a.Min, a.Max)
+	return legacyprotocol.Attribute{
+		Name:    a.Name,
+		Value:   max(minimum, min(maximum, a.Value)),
+		Min:     minimum,
+		Max:     maximum,
+		Default: max(minimum, min(maximum, a.Default)),
+	}, true
+}
+
+// downgradeAttributeValue downgrades the value of an attribute of an entity being spawned to v1.12.0, clamping its
+// values to the bounds v1.12.0 accepts. False is returned if the attribute does not exist in v1.12.0.
+func downgradeAttributeValue(a protocol.AttributeValue) (legacyprotocol.AttributeValue, bool) {
+	b, ok := legacyAttributes[a.Name]
+	if !ok {
+		return legacyprotocol.AttributeValue{}, false
+	}
+	minimum, maximum := b.clamp(a.Min, a.Max)
+	return legacyprotocol.AttributeValue{
+		Name:  a.Name,
+		Min:   minimum,
+		Max:   maximum,
+		Value: max(minimum, min(maximum, a.Value)),
+	}, true
+}
+
+// clamp clamps the minimum and maximum of an attribute to the bounds, making sure the maximum is not lower than the
+// minimum.
+func (b attributeBounds) clamp(minimum, maximum float32) (float32, float32) {
+	minimum = max(b.min, min(b.max, minimum))
+	return minimum, max(minimum, min(b.max, maximum))
+}
+
+// downgradeAttributes downgrades the attributes passed to v1.12.0, leaving out attributes that don't exist in
+// v1.12.0.
+func downgradeAttributes(attributes []protocol.Attribute) []legacyprotocol.Attribute {
+	legacyAttributes := make([]legacyprotocol.Attribute, 0, len(attributes))
+	for _, a := range attributes {
+		if legacyAttribute, ok := downgradeAttribute(a); ok {
+			legacyAttributes = append(legacyAttributes, legacyAttribute)
+		}
+	}
+	return legacyAttributes
+}
+
+// downgradeAttributeValues downgrades the attribute values of an entity being spawned to v1.12.0, leaving out
+// attributes that don't exist in v1.12.0.
+func downgradeAttributeValues(attributes []protocol.AttributeValue) []legacyprotocol.AttributeValue {
+	values := make([]legacyprotocol.AttributeValue, 0, len(attributes))
+	for _, a := range attributes {
+		if value, ok := downgradeAttributeValue(a); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// downgradeMobEffect downgrades a MobEffect packet of the latest version to v1.12.0. Effects added after v1.12.0
+// are replaced with a similar effect, and false is returned if there is none.
+func downgradeMobEffect(pk *packet.MobEffect) (*legacypacket.MobEffect, bool) {
+	effectType, ok := legacymappings.DowngradeEffect(pk.EffectType)
+	if !ok {
+		return nil, false
+	}
+	return &legacypacket.MobEffect{
+		EntityRuntimeID: pk.EntityRuntimeID,
+		Operation:       pk.Operation,
+		EffectType:      effectType,
+		Amplifier:       pk.Amplifier,
+		Particles:       pk.Particles,
+		Duration:        pk.Duration,
+	}, true
+}
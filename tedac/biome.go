@@ -0,0 +1,113 @@
+package tedac
+
+import (
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+)
+
+// customBiomeCandidates holds the names of the v1.12.0 biomes that custom biomes may be shown as. The closest one
+// in climate is chosen for every custom biome.
+var customBiomeCandidates = []string{
+	"plains", "desert", "forest", "taiga", "swampland", "ice_plains", "jungle", "birch_forest", "roofed_forest",
+	"mega_taiga", "savanna", "mesa", "extreme_hills",
+}
+
+// biomeClimate holds the temperature and downfall of a biome, which decide the colours of grass, foliage and
+// water, and whether it rains or snows.
+type biomeClimate struct {
+	Temperature float32 `nbt:"temperature"`
+	Downfall    float32 `nbt:"downfall"`
+}
+
+// legacyBiomeClimates returns the climate of every biome in customBiomeCandidates, indexed by the ID of the biome.
+// The climates are read from the legacy biome definitions the first time they are needed, as those are only decoded
+// in the init function of another file.
+var legacyBiomeClimates = sync.OnceValue(func() map[uint8]biomeClimate {
+	var definitions map[string]biomeClimate
+	if err := nbt.UnmarshalEncoding(legacySerialisedBiomeDefinitions, &definitions, nbt.NetworkLittleEndian); err != nil {
+		panic(err)
+	}
+	climates := make(map[uint8]biomeClimate, len(customBiomeCandidates))
+	for _, name := range customBiomeCandidates {
+		id, ok := legacymappings.BiomeID(name)
+		if !ok {
+			panic("unknown biome " + name)
+		}
+		climates[id] = definitions[name]
+	}
+	return climates
+})
+
+// registerCustomBiomes registers all biomes in the biome definition list passed that don't exist in vanilla as
+// custom biomes of the session. Custom biomes are shown as the v1.12.0 biome closest in climate.
+func (s *session) registerCustomBiomes(pk *packet.BiomeDefinitionList) {
+	biomes := make(map[uint32]uint8)
+	for _, def := range pk.BiomeDefinitions {
+		id, ok := def.BiomeID.Value()
+		if !ok {
+			continue
+		}
+		if _, known := legacymappings.DowngradeBiome(uint32(id)); known {
+			continue
+		}
+		if hasBiomeTag(pk.StringList, def, "nether") {
+			biomes[uint32(id)], _ = legacymappings.BiomeID("hell")
+			continue
+		}
+		if hasBiomeTag(pk.StringList, def, "the_end") {
+			biomes[uint32(id)], _ = legacymappings.BiomeID("the_end")
+			continue
+		}
+		biomes[uint32(id)] = closestBiome(biomeClimate{Temperature: def.Temperature, Downfall: def.Downfall})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customBiomes = biomes
+}
+
+// biomeDowngrader returns a function that downgrades biome IDs of the latest version to the ID of the biome shown
+// to the client, including the custom biomes of the session.
+func (s *session) biomeDowngrader() func(id uint32) uint8 {
+	s.mu.Lock()
+	customBiomes := s.customBiomes
+	s.mu.Unlock()
+
+	return func(id uint32) uint8 {
+		if legacyID, ok := customBiomes[id]; ok {
+			return legacyID
+		}
+		legacyID, _ := legacymappings.DowngradeBiome(id)
+		return legacyID
+	}
+}
+
+// hasBiomeTag checks if the biome definition passed has the tag passed. The tags of a definition are indices into
+// the string list of the packet it was sent in.
+func hasBiomeTag(stringList []string, def protocol.BiomeDefinition, tag string) bool {
+	tags, _ := def.Tags.Value()
+	return slices.ContainsFunc(tags, func(i uint16) bool {
+		return int(i) < len(stringList) && stringList[i] == tag
+	})
+}
+
+// closestBiome returns the ID of the v1.12.0 biome in customBiomeCandidates that is closest to the climate passed.
+func closestBiome(c biomeClimate) uint8 {
+	climates := legacyBiomeClimates()
+	closest, dist := uint8(0), math.Inf(1)
+	for _, name := range customBiomeCandidates {
+		id, _ := legacymappings.BiomeID(name)
+		candidate := climates[id]
+		dt, dd := float64(c.Temperature-candidate.Temperature), float64(c.Downfall-candidate.Downfall)
+		if d := dt*dt + dd*dd; d < dist {
+			closest, dist = id, d
+		}
+	}
+	return closest
+}
@@ -0,0 +1,196 @@
+package tedac
+
+import (
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+)
+
+// blockEntityDowngrades holds a function for every block entity known to v1.12.0, indexed by the ID of the block
+// entity. The function rewrites the NBT of the block entity in the latest version to the shape that v1.12.0
+// expects. Block entities that are not present in the map are not shown by v1.12.0 and are dropped.
+var blockEntityDowngrades = map[string]func(m map[string]any) map[string]any{
+	"Banner":           downgradeBanner,
+	"Barrel":           downgradeContainerBlockEntity,
+	"Beacon":           keepBlockEntity,
+	"Bed":              keepBlockEntity,
+	"Bell":             keepBlockEntity,
+	"BlastFurnace":     downgradeContainerBlockEntity,
+	"BrewingStand":     downgradeContainerBlockEntity,
+	"Campfire":         downgradeCampfire,
+	"Cauldron":         downgradeContainerBlockEntity,
+	"Chest":            downgradeContainerBlockEntity,
+	"CommandBlock":     keepBlockEntity,
+	"Comparator":       keepBlockEntity,
+	"Conduit":          keepBlockEntity,
+	"DaylightDetector": keepBlockEntity,
+	"Dispenser":        downgradeContainerBlockEntity,
+	"Dropper":          downgradeContainerBlockEntity,
+	"EnchantTable":     keepBlockEntity,
+	"EndGateway":       keepBlockEntity,
+	"EndPortal":        keepBlockEntity,
+	"EnderChest":       keepBlockEntity,
+	"FlowerPot":        downgradeFlowerPot,
+	"Furnace":          downgradeContainerBlockEntity,
+	"Hopper":           downgradeContainerBlockEntity,
+	"ItemFrame":        downgradeItemFrame,
+	"Jigsaw":           keepBlockEntity,
+	"Jukebox":          downgradeJukebox,
+	"Lectern":          downgradeLectern,
+	"MobSpawner":       keepBlockEntity,
+	"Music":            keepBlockEntity,
+	"MovingBlock":      keepBlockEntity,
+	"PistonArm":        keepBlockEntity,
+	"ShulkerBox":       downgradeContainerBlockEntity,
+	"Sign":             downgradeSign,
+	"Skull":            keepBlockEntity,
+	"Smoker":           downgradeContainerBlockEntity,
+	"StructureBlock":   keepBlockEntity,
+}
+
+// legacyBannerPatterns holds the IDs of all banner patterns known to v1.12.0.
+var legacyBannerPatterns = map[string]struct{}{
+	"bl": {}, "br": {}, "tl": {}, "tr": {}, "bs": {}, "ts": {}, "ls": {}, "rs": {}, "cs": {}, "ms": {},
+	"drs": {}, "dls": {}, "ss": {}, "cr": {}, "sc": {}, "bt": {}, "tt": {}, "bts": {}, "tts": {}, "ld": {},
+	"rd": {}, "lud": {}, "rud": {}, "mc": {}, "mr": {}, "vh": {}, "hh": {}, "vhr": {}, "hhb": {}, "bo": {},
+	"cbo": {}, "gra": {}, "gru": {}, "bri": {}, "cre": {}, "sku": {}, "flo": {}, "moj": {},
+}
+
+// DowngradeBlockEntity downgrades the NBT of a block entity to the NBT that v1.12.0 expects. False is returned if
+// the block entity is not shown by v1.12.0, in which case it should not be sent to the client.
+func DowngradeBlockEntity(m map[string]any) (map[string]any, bool) {
+	id, _ := m["id"].(string)
+	downgrade, ok := blockEntityDowngrades[id]
+	if !ok {
+		return nil, false
+	}
+	return downgrade(m), true
+}
+
+// upgradeBlockEntity upgrades the NBT of a block entity sent by the client, such as a sign that was edited, to the
+// NBT of the latest version.
+func upgradeBlockEntity(m map[string]any) map[string]any {
+	if id, _ := m["id"].(string); id != "Sign" {
+		return m
+	}
+	text, _ := m["Text"].(string)
+	upgraded := withoutKey(m, "Text")
+	upgraded["FrontText"] = map[string]any{"Text": text}
+	upgraded["BackText"] = map[string]any{"Text": ""}
+	upgraded["IsWaxed"] = uint8(0)
+	return upgraded
+}
+
+// keepBlockEntity returns the NBT of a block entity of which the shape did not change since v1.12.0. Only the
+// custom name is normalised.
+func keepBlockEntity(m map[string]any) map[string]any {
+	if name, ok := m["CustomName"].(string); ok {
+		m = withoutKey(m, "CustomName")
+		m["CustomName"] = downgradeText(name)
+	}
+	return m
+}
+
+// downgradeContainerBlockEntity downgrades a block entity holding items, such as a chest or a furnace.
+func downgradeContainerBlockEntity(m map[string]any) map[string]any {
+	m = keepBlockEntity(m)
+	if items, ok := m["Items"]; ok {
+		m = withoutKey(m, "Items")
+		m["Items"] = convertNBTItems(items, downgradeNBTItem)
+	}
+	return m
+}
+
+// downgradeNBTItemTag downgrades the item held in the tag with the key passed. The tag is removed if the item
+// does not exist in v1.12.0.
+func downgradeNBTItemTag(m map[string]any, key string) map[string]any {
+	item, ok := m[key].(map[string]any)
+	if !ok {
+		return m
+	}
+	m = withoutKey(m, key)
+	if item, ok := downgradeNBTItem(item); ok {
+		m[key] = item
+	}
+	return m
+}
+
+// downgradeSign downgrades a sign. Signs in the latest version have text on both sides, but v1.12.0 only shows
+// the text on the front.
+func downgradeSign(m map[string]any) map[string]any {
+	text, _ := m["Text"].(string)
+	if front, ok := m["FrontText"].(map[string]any); ok {
+		text, _ = front["Text"].(string)
+	}
+	downgraded := map[string]any{"id": "Sign", "Text": downgradeText(text)}
+	for _, key := range []string{"x", "y", "z", "isMovable"} {
+		if v, ok := m[key]; ok {
+			downgraded[key] = v
+		}
+	}
+	return downgraded
+}
+
+// downgradeBanner downgrades a banner, removing the patterns that were added after v1.12.0.
+func downgradeBanner(m map[string]any) map[string]any {
+	patterns, ok := m["Patterns"].([]any)
+	if !ok {
+		return m
+	}
+	legacyPatterns := make([]any, 0, len(patterns))
+	for _, p := range patterns {
+		pattern, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		if id, _ := pattern["Pattern"].(string); !hasKey(legacyBannerPatterns, id) {
+			continue
+		}
+		legacyPatterns = append(legacyPatterns, pattern)
+	}
+	m = withoutKey(m, "Patterns")
+	m["Patterns"] = legacyPatterns
+	return m
+}
+
+// downgradeFlowerPot downgrades a flower pot. The latest version stores the plant in the pot as a block state,
+// whereas v1.12.0 stores its legacy ID and metadata value.
+func downgradeFlowerPot(m map[string]any) map[string]any {
+	plant, ok := m["PlantBlock"].(map[string]any)
+	if !ok {
+		return m
+	}
+	m = withoutKey(m, "PlantBlock")
+	name, _ := plant["name"].(string)
+	properties, _ := plant["states"].(map[string]any)
+	entry := legacymappings.Blocks()[legacymappings.StateToRuntimeID(name, properties)]
+	m["item"], m["mData"] = entry.LegacyID, int32(entry.Data)
+	return m
+}
+
+// downgradeItemFrame downgrades an item frame and the item held in it.
+func downgradeItemFrame(m map[string]any) map[string]any {
+	return downgradeNBTItemTag(m, "Item")
+}
+
+// downgradeJukebox downgrades a jukebox and the record played by it.
+func downgradeJukebox(m map[string]any) map[string]any {
+	return downgradeNBTItemTag(m, "RecordItem")
+}
+
+// downgradeLectern downgrades a lectern and the book placed on it.
+func downgradeLectern(m map[string]any) map[string]any {
+	return downgradeNBTItemTag(m, "book")
+}
+
+// downgradeCampfire downgrades a campfire and the items cooking on it.
+func downgradeCampfire(m map[string]any) map[string]any {
+	for _, key := range []string{"Item1", "Item2", "Item3", "Item4"} {
+		m = downgradeNBTItemTag(m, key)
+	}
+	return m
+}
+
+// hasKey checks if the set passed holds the key passed.
+func hasKey(set map[string]struct{}, key string) bool {
+	_, ok := set[key]
+	return ok
+}
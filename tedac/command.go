@@ -0,0 +1,188 @@
+package tedac
+
+import (
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol/legacypacket"
+)
+
+// commandArgTypes maps the command parameter types of the latest version to the type shown in v1.12.0. Types that
+// are not in the map are shown as strings.
+var commandArgTypes = map[uint32]uint32{
+	protocol.CommandArgTypeInt:             legacyprotocol.CommandArgTypeInt,
+	protocol.CommandArgTypeFloat:           legacyprotocol.CommandArgTypeFloat,
+	protocol.CommandArgTypeValue:           legacyprotocol.CommandArgTypeValue,
+	protocol.CommandArgTypeWildcardInt:     legacyprotocol.CommandArgTypeWildcardInt,
+	protocol.CommandArgTypeOperator:        legacyprotocol.CommandArgTypeOperator,
+	protocol.CommandArgTypeCompareOperator: legacyprotocol.CommandArgTypeOperator,
+	protocol.CommandArgTypeTarget:          legacyprotocol.CommandArgTypeTarget,
+	protocol.CommandArgTypeWildcardTarget:  legacyprotocol.CommandArgTypeTarget,
+	protocol.CommandArgTypeFilepath:        legacyprotocol.CommandArgTypeFilepath,
+	protocol.CommandArgTypeString:          legacyprotocol.CommandArgTypeString,
+	protocol.CommandArgTypeBlockPosition:   legacyprotocol.CommandArgTypePosition,
+	protocol.CommandArgTypePosition:        legacyprotocol.CommandArgTypePosition,
+	protocol.CommandArgTypeMessage:         legacyprotocol.CommandArgTypeMessage,
+	protocol.CommandArgTypeRawText:         legacyprotocol.CommandArgTypeRawText,
+	protocol.CommandArgTypeJSON:            legacyprotocol.CommandArgTypeJSON,
+	protocol.CommandArgTypeCommand:         legacyprotocol.CommandArgTypeCommand,
+}
+
+// downgradeCommands downgrades the commands of an AvailableCommands packet to v1.12.0. Enums, dynamic enums and
+// suffixes are resolved from the packet, as v1.12.0 indexes them differently.
+func downgradeCommands(pk *packet.AvailableCommands) []legacyprotocol.Command {
+	enum := func(index uint32) legacyprotocol.CommandEnum {
+		e := pk.Enums[index]
+		options := make([]string, 0, len(e.ValueIndices))
+		for _, i := range e.ValueIndices {
+			if int(i) < len(pk.EnumValues) {
+				options = append(options, pk.EnumValues[i])
+			}
+		}
+		return legacyprotocol.CommandEnum{Type: e.Type, Options: options}
+	}
+
+	commands := make([]legacyprotocol.Command, 0, len(pk.Commands))
+	for _, c := range pk.Commands {
+		command := legacyprotocol.Command{
+			Name:            c.Name,
+			Description:     c.Description,
+			Flags:           byte(c.Flags),
+			PermissionLevel: c.PermissionLevel,
+		}
+		if c.AliasesOffset != math.MaxUint32 && int(c.AliasesOffset) < len(pk.Enums) {
+			command.Aliases = slices.DeleteFunc(enum(c.AliasesOffset).Options, func(alias string) bool {
+				return alias == c.Name
+			})
+		}
+		for _, o := range c.Overloads {
+			overload := downgradeCommandOverload(pk, o, enum)
+			if !slices.ContainsFunc(command.Overloads, func(other legacyprotocol.CommandOverload) bool {
+				return commandOverloadsEqual(other, overload)
+			}) {
+				command.Overloads = append(command.Overloads, overload)
+			}
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// downgradeCommandOverload downgrades a command overload to v1.12.0. v1.12.0 cannot show chained subcommands, so
+// the parameters starting at the first one that cannot be shown are flattened into a single raw text parameter that
+// accepts the rest of the command.
+func downgradeCommandOverload(pk *packet.AvailableCommands, o protocol.CommandOverload, enum func(index uint32) legacyprotocol.CommandEnum) legacyprotocol.CommandOverload {
+	var overload legacyprotocol.CommandOverload
+	for _, p := range o.Parameters {
+		param := legacyprotocol.CommandParameter{
+			Name:                p.Name,
+			Optional:            p.Optional,
+			CollapseEnumOptions: p.Options&protocol.ParamOptionCollapseEnum != 0,
+		}
+		index := p.Type & 0xffff
+		switch {
+		case p.Type&protocol.CommandArgSoftEnum != 0 && int(index) < len(pk.DynamicEnums):
+			e := pk.DynamicEnums[index]
+			param.Enum = legacyprotocol.CommandEnum{Type: e.Type, Options: e.Values, Dynamic: true}
+		case p.Type&protocol.CommandArgEnum != 0 && int(index) < len(pk.Enums):
+			if param.Enum = enum(index); len(param.Enum.Options) == 0 {
+				param.Type = legacyprotocol.CommandArgValid | legacyprotocol.CommandArgTypeString
+			}
+		case p.Type&protocol.CommandArgSuffixed != 0 && int(index) < len(pk.Suffixes):
+			param.Type, param.Suffix = legacyprotocol.CommandArgTypeInt, pk.Suffixes[index]
+		case p.Type&protocol.CommandArgValid != 0 && p.Options&protocol.ParamOptionAsChainedCommand == 0:
+			argType, ok := commandArgTypes[index]
+			if !ok {
+				argType = legacyprotocol.CommandArgTypeString
+			}
+			param.Type = legacyprotocol.CommandArgValid | argType
+		default:
+			param.Type = legacyprotocol.CommandArgValid | legacyprotocol.CommandArgTypeRawText
+			overload.Parameters = append(overload.Parameters, param)
+			return overload
+		}
+		overload.Parameters = append(overload.Parameters, param)
+	}
+	return overload
+}
+
+// commandOverloadsEqual checks if two command overloads are shown the same by v1.12.0.
+func commandOverloadsEqual(a, b legacyprotocol.CommandOverload) bool {
+	return slices.EqualFunc(a.Parameters, b.Parameters, func(x, y legacyprotocol.CommandParameter) bool {
+		return x.Name == y.Name && x.Type == y.Type && x.Optional == y.Optional && x.Enum.Type == y.Enum.Type && x.Suffix == y.Suffix
+	})
+}
+
+// maxPendingCommands is the maximum amount of commands of which the origin is kept while waiting for their output.
+const maxPendingCommands = 64
+
+// upgradeCommandOrigin upgrades the origin of a command requested by the client to the latest version. The client
+// leaves the request ID empty for commands entered in chat, so a request ID is created to match the output of the
+// server with the origin of the command again.
+func (s *session) upgradeCommandOrigin(origin legacyprotocol.CommandOrigin) protocol.CommandOrigin {
+	requestID := origin.RequestID
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	originType := origin.Origin
+	if originType > legacyprotocol.CommandOriginEntityServer {
+		originType = legacyprotocol.CommandOriginPlayer
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.commandOrigins == nil || len(s.commandOrigins) >= maxPendingCommands {
+		// Many servers never send command output, so origins are dropped once too many have piled up.
+		s.commandOrigins = make(map[string]legacyprotocol.CommandOrigin)
+	}
+	s.commandOrigins[requestID] = origin
+	return protocol.CommandOrigin{
+		Origin:         originType,
+		UUID:           origin.UUID,
+		RequestID:      requestID,
+		PlayerUniqueID: origin.PlayerUniqueID,
+	}
+}
+
+// downgradeCommandOutput downgrades the output of a command to v1.12.0. The output is sent with the origin that the
+// client requested the command with, so that the client shows it in the chat.
+func (s *session) downgradeCommandOutput(pk *packet.CommandOutput) *legacypacket.CommandOutput {
+	s.mu.Lock()
+	origin, ok := s.commandOrigins[pk.CommandOrigin.RequestID]
+	delete(s.commandOrigins, pk.CommandOrigin.RequestID)
+	s.mu.Unlock()
+	if !ok {
+		origin = legacyprotocol.CommandOrigin{
+			Origin:         pk.CommandOrigin.Origin,
+			UUID:           pk.CommandOrigin.UUID,
+			RequestID:      pk.CommandOrigin.RequestID,
+			PlayerUniqueID: pk.CommandOrigin.PlayerUniqueID,
+		}
+		if origin.Origin > legacyprotocol.CommandOriginEntityServer {
+			origin.Origin = legacyprotocol.CommandOriginPlayer
+		}
+	}
+
+	outputType := pk.OutputType
+	if outputType > legacyprotocol.CommandOutputTypeDataSet {
+		outputType = legacyprotocol.CommandOutputTypeAllOutput
+	}
+	return &legacypacket.CommandOutput{
+		CommandOrigin: origin,
+		OutputType:    outputType,
+		SuccessCount:  pk.SuccessCount,
+		OutputMessages: lo.Map(pk.OutputMessages, func(m protocol.CommandOutputMessage, _ int) legacyprotocol.CommandOutputMessage {
+			return legacyprotocol.CommandOutputMessage{
+				Success:    m.Success,
+				Message:    m.Message,
+				Parameters: m.Parameters,
+			}
+		}),
+		DataSet: pk.DataSet,
+	}
+}
@@ -0,0 +1,104 @@
+package tedac
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+)
+
+// Coverage describes how complete the translation of blocks and items of the latest version to v1.12.0 is.
+type Coverage struct {
+	Blocks CoverageEntries `json:"blocks"`
+	Items  CoverageEntries `json:"items"`
+}
+
+// CoverageEntries describes the coverage of a single kind of entry, such as block states or items.
+type CoverageEntries struct {
+	// Total is the amount of entries in the latest version.
+	Total int `json:"total"`
+	// Unmapped holds the entries that have no v1.12.0 equivalent and are shown as the default fallback, such as
+	// an update block or a name tag.
+	Unmapped []string `json:"unmapped"`
+	// Fallback holds the entries that are shown as a fallback configured by the user.
+	Fallback []string `json:"fallback"`
+	// Lossy holds the entries that are mapped to v1.12.0, but that are not translated back to the same entry.
+	Lossy []string `json:"lossy"`
+}
+
+// MappingCoverage walks every vanilla block state and item of the latest version, downgrades it to v1.12.0 and
+// upgrades it back, and reports the entries that are not translated properly.
+func MappingCoverage() Coverage {
+	return Coverage{Blocks: blockCoverage(), Items: itemCoverage()}
+}
+
+// blockCoverage reports the coverage of all vanilla block states.
+func blockCoverage() CoverageEntries {
+	infoUpdateRID := legacymappings.StateToRuntimeID("minecraft:info_update", nil)
+	palette := latestmappings.VanillaPalette()
+
+	var c CoverageEntries
+	for _, s := range latestmappings.States() {
+		rid, ok := palette.StateToRuntimeID(s.Name, s.Properties)
+		if !ok {
+			continue
+		}
+		c.Total++
+
+		desc := describeState(s.Name, s.Properties)
+		if _, ok := blockFallbackFor(s.Name, s.Properties); ok {
+			c.Fallback = append(c.Fallback, desc)
+			continue
+		}
+		legacyRID := downgradeBlockRuntimeID(rid, palette, nil)
+		if legacyRID == infoUpdateRID && s.Name != "minecraft:info_update" {
+			c.Unmapped = append(c.Unmapped, desc)
+			continue
+		}
+		if upgradeBlockRuntimeID(legacyRID, palette) != rid {
+			c.Lossy = append(c.Lossy, desc)
+		}
+	}
+	return c
+}
+
+// itemCoverage reports the coverage of all vanilla items.
+func itemCoverage() CoverageEntries {
+	s := &session{}
+
+	var c CoverageEntries
+	for _, name := range latestmappings.ItemNames() {
+		rid, _ := latestmappings.ItemNameToRuntimeID(name)
+		c.Total++
+
+		if _, ok := itemFallback(name); ok {
+			c.Fallback = append(c.Fallback, name)
+			continue
+		}
+		item, ok := s.tryDowngradeItem(protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: rid}, Count: 1})
+		if !ok {
+			c.Unmapped = append(c.Unmapped, name)
+			continue
+		}
+		if upgraded := s.upgradeItem(item); upgraded.NetworkID != rid || upgraded.MetadataValue != 0 {
+			c.Lossy = append(c.Lossy, name)
+		}
+	}
+	return c
+}
+
+// describeState returns a readable description of a block state, such as 'minecraft:oak_log[pillar_axis=y]'.
+func describeState(name string, properties map[string]any) string {
+	if len(properties) == 0 {
+		return name
+	}
+	pairs := make([]string, 0, len(properties))
+	for k, v := range properties {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", k, v))
+	}
+	sort.Strings(pairs)
+	return name + "[" + strings.Join(pairs, ",") + "]"
+}
@@ -0,0 +1,55 @@
+package tedac
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// coverageBaseline holds the amount of entries of each kind that are allowed to not be translated properly.
+type coverageBaseline struct {
+	Blocks coverageLimit `json:"blocks"`
+	Items  coverageLimit `json:"items"`
+}
+
+// coverageLimit holds the amount of unmapped and lossy entries of a single kind in the baseline.
+type coverageLimit struct {
+	Unmapped int `json:"unmapped"`
+	Lossy    int `json:"lossy"`
+}
+
+// TestMappingCoverage checks that the coverage of the mappings does not drop below the baseline in testdata. The
+// baseline should be lowered whenever the coverage improves, so that later changes cannot undo the improvement.
+func TestMappingCoverage(t *testing.T) {
+	data, err := os.ReadFile("testdata/coverage_baseline.json")
+	if err != nil {
+		t.Fatalf("read baseline: %v", err)
+	}
+	var baseline coverageBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		t.Fatalf("decode baseline: %v", err)
+	}
+
+	c := MappingCoverage()
+	checkCoverage(t, "blocks", c.Blocks, baseline.Blocks)
+	checkCoverage(t, "items", c.Items, baseline.Items)
+}
+
+// checkCoverage compares the coverage entries passed against the limit of the baseline.
+func checkCoverage(t *testing.T, kind string, entries CoverageEntries, limit coverageLimit) {
+	t.Helper()
+	for _, check := range []struct {
+		name     string
+		n, limit int
+	}{
+		{name: "unmapped", n: len(entries.Unmapped), limit: limit.Unmapped},
+		{name: "lossy", n: len(entries.Lossy), limit: limit.Lossy},
+	} {
+		switch {
+		case check.n > check.limit:
+			t.Errorf("%v %v: %v entries, baseline allows %v", check.name, kind, check.n, check.limit)
+		case check.n < check.limit:
+			t.Logf("%v %v: %v entries, baseline may be lowered from %v", check.name, kind, check.n, check.limit)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package tedac
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+)
+
+// craftingRecipe is a crafting recipe that was sent to the legacy client. It holds the network ID the server
+// assigned to the recipe, so that crafting results can be translated to the recipe the client used.
+type craftingRecipe struct {
+	networkID uint32
+	input     []legacyprotocol.ItemStack
+	output    legacyprotocol.ItemStack
+}
+
+// downgradeRecipes downgrades the recipes passed to their v1.12.0 equivalents. Recipes that require items or
+// crafting stations not available in v1.12.0 are dropped. The crafting recipes are stored in the session, so
+// that crafting transactions of the client can be translated later on. The recipes stored previously are
+// removed if clear is true.
+func (s *session) downgradeRecipes(recipes []protocol.Recipe, clear bool) []legacyprotocol.Recipe {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if clear {
+		s.recipes = nil
+	}
+
+	downgraded := make([]legacyprotocol.Recipe, 0, len(recipes))
+	for _, r := range recipes {
+		switch r := r.(type) {
+		case *protocol.ShapelessRecipe:
+			input, output, ok := s.downgradeRecipeItems(r.Block, r.Input, r.Output)
+			if !ok {
+				continue
+			}
+			s.recipes = append(s.recipes, craftingRecipe{networkID: r.RecipeNetworkID, input: input, output: output[0]})
+			downgraded = append(downgraded, &legacyprotocol.ShapelessRecipe{Input: input, Output: output, UUID: r.UUID})
+		case *protocol.ShapedRecipe:
+			input, output, ok := s.downgradeRecipeItems(r.Block, r.Input, r.Output)
+			if !ok {
+				continue
+			}
+			s.recipes = append(s.recipes, craftingRecipe{networkID: r.RecipeNetworkID, input: input, output: output[0]})
+			downgraded = append(downgraded, &legacyprotocol.ShapedRecipe{
+				Width:  r.Width,
+				Height: r.Height,
+				Input:  input,
+				Output: output,
+				UUID:   r.UUID,
+			})
+		case *protocol.FurnaceRecipe:
+			if recipe, ok := s.downgradeFurnaceRecipe(r); ok {
+				downgraded = append(downgraded, &recipe)
+			}
+		case *protocol.FurnaceDataRecipe:
+			if recipe, ok := s.downgradeFurnaceRecipe(&r.FurnaceRecipe); ok {
+				downgraded = append(downgraded, &legacyprotocol.FurnaceDataRecipe{FurnaceRecipe: recipe})
+			}
+		case *protocol.MultiRecipe:
+			downgraded = append(downgraded, &legacyprotocol.MultiRecipe{UUID: r.UUID})
+		}
+	}
+	return downgraded
+}
+
+// downgradeRecipeItems downgrades the input and output of a crafting recipe. False is returned if the recipe
+// is not crafted in a crafting table, or if any of its items do not exist in v1.12.0.
+func (s *session) downgradeRecipeItems(block string, input []protocol.ItemDescriptorCount, output []protocol.ItemStack) ([]legacyprotocol.ItemStack, []legacyprotocol.ItemStack, bool) {
+	if block != "crafting_table" || len(output) == 0 {
+		return nil, nil, false
+	}
+	downgradedInput := make([]legacyprotocol.ItemStack, 0, len(input))
+	for _, i := range input {
+		item, ok := s.downgradeDescriptor(i)
+		if !ok {
+			return nil, nil, false
+		}
+		downgradedInput = append(downgradedInput, item)
+	}
+	downgradedOutput := make([]legacyprotocol.ItemStack, 0, len(output))
+	for _, o := range output {
+		item, ok := s.tryDowngradeItem(o)
+		if !ok {
+			return nil, nil, false
+		}
+		downgradedOutput = append(downgradedOutput, item)
+	}
+	return downgradedInput, downgradedOutput, true
+}
+
+// downgradeFurnaceRecipe downgrades a furnace recipe. False is returned if the recipe is not used by regular
+// furnaces, or if its input or output does not exist in v1.12.0.
+func (s *session) downgradeFurnaceRecipe(r *protocol.FurnaceRecipe) (legacyprotocol.FurnaceRecipe, bool) {
+	if r.Block != "furnace" {
+		return legacyprotocol.FurnaceRecipe{}, false
+	}
+	input, ok := s.tryDowngradeItem(protocol.ItemStack{ItemType: r.InputType, Count: 1})
+	if !ok {
+		return legacyprotocol.FurnaceRecipe{}, false
+	}
+	output, ok := s.tryDowngradeItem(r.Output)
+	if !ok {
+		return legacyprotocol.FurnaceRecipe{}, false
+	}
+	return legacyprotocol.FurnaceRecipe{InputType: input.ItemType, Output: output}, true
+}
+
+// downgradeDescriptor downgrades an item descriptor of a recipe input to a legacy item stack. False is
+// returned if the descriptor has no v1.12.0 equivalent.
+func (s *session) downgradeDescriptor(d protocol.ItemDescriptorCount) (legacyprotocol.ItemStack, bool) {
+	var networkID int32
+	var metadataValue int16
+	switch desc := d.Descriptor.(type) {
+	case *protocol.InvalidItemDescriptor:
+		// Empty slots in shaped recipes have an invalid descriptor.
+		return legacyprotocol.ItemStack{}, true
+	case *protocol.DefaultItemDescriptor:
+		if desc.NetworkID == 0 {
+			return legacyprotocol.ItemStack{}, true
+		}
+		networkID, metadataValue = int32(desc.NetworkID), desc.MetadataValue
+	case *protocol.DeferredItemDescriptor:
+		rid, ok := latestmappings.ItemNameToRuntimeID(desc.Name)
+		if !ok {
+			return legacyprotocol.ItemStack{}, false
+		}
+		networkID, metadataValue = rid, desc.MetadataValue
+	default:
+		// Item tags and molecules have no representation in v1.12.0.
+		return legacyprotocol.ItemStack{}, false
+	}
+	return s.tryDowngradeItem(protocol.ItemStack{
+		ItemType: protocol.ItemType{NetworkID: networkID, MetadataValue: uint32(metadataValue)},
+		Count:    uint16(d.Count),
+	})
+}
+
+// craftingRequest translates a legacy inventory transaction that interacts with the crafting grid to an item
+// stack request. False is returned if the actions passed don't involve the crafting grid, or if no recipe
+// produces the crafting result taken, in which case the transaction is sent as is.
+func (s *session) craftingRequest(actions []legacyprotocol.InventoryAction) (*packet.ItemStackRequest, bool) {
+	var result *legacyprotocol.InventoryAction
+	var grid, containers []legacyprotocol.InventoryAction
+	for i, action := range actions {
+		switch action.SourceType {
+		case legacyprotocol.InventoryActionSourceTODO:
+			switch action.WindowID {
+			case legacyprotocol.WindowIDCraftingAddIngredient, legacyprotocol.WindowIDCraftingRemoveIngredient:
+				grid = append(grid, action)
+			case legacyprotocol.WindowIDCraftingResult:
+				result = &actions[i]
+			}
+		case legacyprotocol.InventoryActionSourceContainer:
+			containers = append(containers, action)
+		}
+	}
+	if result == nil && len(grid) == 0 {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if result != nil {
+		return s.craftRequest(result, containers)
+	}
+	return s.gridRequest(grid, containers), true
+}
+
+// gridRequest translates the placing and removing of ingredients in the crafting grid to place actions between
+// the container slots and the crafting input. s.mu must be held when calling this method.
+func (s *session) gridRequest(grid, containers []legacyprotocol.InventoryAction) *packet.ItemStackRequest {
+	offset := s.craftingGridOffset()
+
+	var stackActions []protocol.StackRequestAction
+	var changed []slotChange
+	for _, g := range grid {
+		gridSlot := windowSlot{window: legacyprotocol.WindowIDUI, slot: offset + g.InventorySlot}
+		delta := int(g.NewItem.Count) - int(g.OldItem.Count)
+		for _, c := range containers {
+			containerDelta := int(c.OldItem.Count) - int(c.NewItem.Count)
+			if containerDelta == 0 || (delta > 0) != (containerDelta > 0) {
+				continue
+			}
+			count := min(abs(delta), abs(containerDelta))
+			src, dst := s.slotInfo(c.WindowID, c.InventorySlot), s.slotInfo(legacyprotocol.WindowIDUI, gridSlot.slot)
+			if delta < 0 {
+				src, dst = dst, src
+			}
+			stackActions = append(stackActions, placeAction(byte(count), src, dst))
+			changed = append(changed,
+				slotChange{windowSlot: gridSlot, item: g.NewItem},
+				slotChange{windowSlot: windowSlot{window: uint32(c.WindowID), slot: c.InventorySlot}, item: c.NewItem},
+			)
+			break
+		}
+		if g.NewItem.Count == 0 {
+			delete(s.craftingGrid, g.InventorySlot)
+		} else {
+			s.craftingGrid[g.InventorySlot] = g.NewItem
+		}
+	}
+	return s.stackRequest(stackActions, changed...)
+}
+
+// craftRequest translates the taking of a crafting result to a request that crafts the matching recipe,
+// consumes the ingredients in the crafting grid and places the result in the destination slots. s.mu must be
+// held when calling this method. False is returned if no recipe produces the result.
+func (s *session) craftRequest(result *legacyprotocol.InventoryAction, containers []legacyprotocol.InventoryAction) (*packet.ItemStackRequest, bool) {
+	output := result.OldItem
+	if output.NetworkID == 0 {
+		output = result.NewItem
+	}
+	recipe, ok := s.matchRecipe(output)
+	if !ok {
+		return nil, false
+	}
+
+	crafts := byte(1)
+	if recipe.output.Count > 0 && output.Count > recipe.output.Count {
+		crafts = byte(output.Count / recipe.output.Count)
+	}
+	stackActions := []protocol.StackRequestAction{
+		&protocol.CraftRecipeStackRequestAction{RecipeNetworkID: recipe.networkID, NumberOfCrafts: crafts},
+		&protocol.CraftResultsDeprecatedStackRequestAction{
+			ResultItems:  []protocol.ItemStack{s.upgradeItem(output)},
+			TimesCrafted: crafts,
+		},
+	}
+
+	offset := s.craftingGridOffset()
+	var changed []slotChange
+	for slot, item := range s.craftingGrid {
+		gridSlot := windowSlot{window: legacyprotocol.WindowIDUI, slot: offset + slot}
+		consume := &protocol.ConsumeStackRequestAction{}
+		consume.Count, consume.Source = crafts, s.slotInfo(legacyprotocol.WindowIDUI, gridSlot.slot)
+		stackActions = append(stackActions, consume)
+
+		if item.Count <= int16(crafts) {
+			delete(s.craftingGrid, slot)
+			changed = append(changed, slotChange{windowSlot: gridSlot})
+			continue
+		}
+		item.Count -= int16(crafts)
+		s.craftingGrid[slot] = item
+		changed = append(changed, slotChange{windowSlot: gridSlot, item: item})
+	}
+
+	for _, c := range containers {
+		count := int(c.NewItem.Count) - int(c.OldItem.Count)
+		if count <= 0 || c.NewItem.ItemType != output.ItemType {
+			continue
+		}
+		src := s.slotInfo(legacyprotocol.WindowIDUI, createdOutputSlot)
+		stackActions = append(stackActions, placeAction(byte(count), src, s.slotInfo(c.WindowID, c.InventorySlot)))
+		changed = append(changed, slotChange{windowSlot: windowSlot{window: uint32(c.WindowID), slot: c.InventorySlot}, item: c.NewItem})
+	}
+	return s.stackRequest(stackActions, changed...), true
+}
+
+// matchRecipe finds the crafting recipe that produces the output passed. If multiple recipes produce the same
+// output, the one of which the input matches the crafting grid is preferred. False is returned if no recipe
+// produces the output. s.mu must be held when calling this method.
+func (s *session) matchRecipe(output legacyprotocol.ItemStack) (craftingRecipe, bool) {
+	var match craftingRecipe
+	var found bool
+	for _, recipe := range s.recipes {
+		if recipe.output.ItemType != output.ItemType {
+			continue
+		}
+		if s.gridMatches(recipe.input) {
+			return recipe, true
+		}
+		if !found {
+			match, found = recipe, true
+		}
+	}
+	return match, found
+}
+
+// gridMatches checks if the items in the crafting grid match the input of a recipe, regardless of the slots
+// they are in. s.mu must be held when calling this method.
+func (s *session) gridMatches(input []legacyprotocol.ItemStack) bool {
+	remaining := make([]legacyprotocol.ItemStack, 0, len(s.craftingGrid))
+	for _, item := range s.craftingGrid {
+		remaining = append(remaining, item)
+	}
+	for _, in := range input {
+		if in.NetworkID == 0 {
+			continue
+		}
+		found := false
+		for i, item := range remaining {
+			if item.NetworkID == in.NetworkID && (in.MetadataValue == legacymappings.MetadataWildcard || item.MetadataValue == in.MetadataValue) {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(remaining) == 0
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
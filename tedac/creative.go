@@ -0,0 +1,114 @@
+package tedac
+
+import (
+	"reflect"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+)
+
+// creativeItem is an item shown in the creative inventory of the legacy client. It holds the creative item
+// network ID that the server assigned to it, so that items picked from the creative inventory can be
+// requested from the server.
+type creativeItem struct {
+	networkID uint32
+	item      legacyprotocol.ItemStack
+}
+
+// downgradeCreativeContent downgrades the creative items passed to the content of the legacy creative window.
+// Items that don't exist in v1.12.0 are left out. The items are stored in the session, so that creative
+// transactions of the client can be translated later on.
+func (s *session) downgradeCreativeContent(items []protocol.CreativeItem) []legacyprotocol.ItemStack {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.creativeItems = s.creativeItems[:0]
+	content := make([]legacyprotocol.ItemStack, 0, len(items))
+	for _, i := range items {
+		item, ok := s.tryDowngradeItem(i.Item)
+		if !ok {
+			continue
+		}
+		s.creativeItems = append(s.creativeItems, creativeItem{networkID: i.CreativeItemNetworkID, item: item})
+		content = append(content, item)
+	}
+	return content
+}
+
+// creativeRequest translates a legacy inventory transaction that takes items from or puts items in the
+// creative inventory to an item stack request. False is returned if the actions passed don't involve the
+// creative inventory.
+func (s *session) creativeRequest(actions []legacyprotocol.InventoryAction) (*packet.ItemStackRequest, bool) {
+	var creative *legacyprotocol.InventoryAction
+	var containers []legacyprotocol.InventoryAction
+	for i, action := range actions {
+		switch action.SourceType {
+		case legacyprotocol.InventoryActionSourceCreative:
+			creative = &actions[i]
+		case legacyprotocol.InventoryActionSourceContainer:
+			containers = append(containers, action)
+		}
+	}
+	if creative == nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stackActions []protocol.StackRequestAction
+	var changed []slotChange
+	if creative.InventorySlot == legacyprotocol.CreativeSlotCreate {
+		// The client took an item from the creative inventory. The item taken is held by the old item of the
+		// action, as it leaves the creative inventory.
+		item, ok := s.matchCreativeItem(creative.OldItem)
+		if !ok {
+			return nil, false
+		}
+		stackActions = append(stackActions, &protocol.CraftCreativeStackRequestAction{
+			CreativeItemNetworkID: item.networkID,
+			NumberOfCrafts:        1,
+		})
+		for _, c := range containers {
+			count := int(c.NewItem.Count) - int(c.OldItem.Count)
+			if count <= 0 {
+				continue
+			}
+			src := s.slotInfo(legacyprotocol.WindowIDUI, createdOutputSlot)
+			stackActions = append(stackActions, placeAction(byte(count), src, s.slotInfo(c.WindowID, c.InventorySlot)))
+			changed = append(changed, slotChange{windowSlot: windowSlot{window: uint32(c.WindowID), slot: c.InventorySlot}, item: c.NewItem})
+		}
+	} else {
+		// The client put an item back in the creative inventory, which destroys it.
+		for _, c := range containers {
+			count := int(c.OldItem.Count) - int(c.NewItem.Count)
+			if count <= 0 {
+				continue
+			}
+			destroy := &protocol.DestroyStackRequestAction{Count: byte(count), Source: s.slotInfo(c.WindowID, c.InventorySlot)}
+			stackActions = append(stackActions, destroy)
+			changed = append(changed, slotChange{windowSlot: windowSlot{window: uint32(c.WindowID), slot: c.InventorySlot}, item: c.NewItem})
+		}
+	}
+	return s.stackRequest(stackActions, changed...), true
+}
+
+// matchCreativeItem finds the creative item matching the legacy item passed. Items with the same NBT are
+// preferred over items that only have the same type. s.mu must be held when calling this method.
+func (s *session) matchCreativeItem(item legacyprotocol.ItemStack) (creativeItem, bool) {
+	var match creativeItem
+	var found bool
+	for _, c := range s.creativeItems {
+		if c.item.ItemType != item.ItemType {
+			continue
+		}
+		if len(c.item.NBTData) == len(item.NBTData) && (len(item.NBTData) == 0 || reflect.DeepEqual(c.item.NBTData, item.NBTData)) {
+			return c, true
+		}
+		if !found {
+			match, found = c, true
+		}
+	}
+	return match, found
+}
@@ -0,0 +1,62 @@
+package tedac
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+)
+
+// creativeStone is the item used by the creative tests, a full stack of stone.
+var creativeStone = legacyprotocol.ItemStack{ItemType: legacyprotocol.ItemType{NetworkID: 1}, Count: 64}
+
+// TestCreativeRequestPick checks that taking an item from the creative inventory crafts the creative item and
+// places it in the destination slot.
+func TestCreativeRequestPick(t *testing.T) {
+	s := newSession()
+	s.creativeItems = []creativeItem{{networkID: 7, item: creativeStone}}
+
+	// v1.12.0 sends the item taken as the old item of the creative action, as it leaves the creative inventory.
+	request, ok := s.creativeRequest([]legacyprotocol.InventoryAction{
+		{SourceType: legacyprotocol.InventoryActionSourceCreative, InventorySlot: legacyprotocol.CreativeSlotCreate, OldItem: creativeStone},
+		{SourceType: legacyprotocol.InventoryActionSourceContainer, WindowID: legacyprotocol.WindowIDInventory, InventorySlot: 3, NewItem: creativeStone},
+	})
+	if !ok {
+		t.Fatalf("creativeRequest returned false for a pick")
+	}
+	actions := request.Requests[0].Actions
+	if len(actions) != 2 {
+		t.Fatalf("pick produced %v actions, want 2", len(actions))
+	}
+	craft, ok := actions[0].(*protocol.CraftCreativeStackRequestAction)
+	if !ok || craft.CreativeItemNetworkID != 7 {
+		t.Errorf("first action = %#v, want crafting creative item 7", actions[0])
+	}
+	place, ok := actions[1].(*protocol.PlaceStackRequestAction)
+	if !ok || place.Count != 64 || place.Destination.Slot != 3 || place.Destination.Container.ContainerID != protocol.ContainerHotBar {
+		t.Errorf("second action = %#v, want placing 64 items in hot bar slot 3", actions[1])
+	}
+}
+
+// TestCreativeRequestDelete checks that putting an item back in the creative inventory destroys it.
+func TestCreativeRequestDelete(t *testing.T) {
+	s := newSession()
+	s.creativeItems = []creativeItem{{networkID: 7, item: creativeStone}}
+
+	// v1.12.0 sends the item deleted as the new item of the creative action, as it enters the creative inventory.
+	request, ok := s.creativeRequest([]legacyprotocol.InventoryAction{
+		{SourceType: legacyprotocol.InventoryActionSourceContainer, WindowID: legacyprotocol.WindowIDInventory, InventorySlot: 3, OldItem: creativeStone},
+		{SourceType: legacyprotocol.InventoryActionSourceCreative, InventorySlot: legacyprotocol.CreativeSlotDelete, NewItem: creativeStone},
+	})
+	if !ok {
+		t.Fatalf("creativeRequest returned false for a delete")
+	}
+	actions := request.Requests[0].Actions
+	if len(actions) != 1 {
+		t.Fatalf("delete produced %v actions, want 1", len(actions))
+	}
+	destroy, ok := actions[0].(*protocol.DestroyStackRequestAction)
+	if !ok || destroy.Count != 64 || destroy.Source.Slot != 3 || destroy.Source.Container.ContainerID != protocol.ContainerHotBar {
+		t.Errorf("action = %#v, want destroying 64 items in hot bar slot 3", actions[0])
+	}
+}
@@ -0,0 +1,130 @@
+package tedac
+
+import (
+	"strings"
+
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+	"github.com/tedacmc/tedac/tedac/legacychunk"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+)
+
+// adjustCustomBlocks creates the block palette of the session to account for the custom blocks sent in the
+// StartGame packet and chooses the legacy block shown in place of each of them. Fallbacks configured by the user take
+// precedence over the blocks chosen here. Servers without custom blocks keep the vanilla palette.
+func (s *session) adjustCustomBlocks(entries []protocol.BlockEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	var customStates []blockupgrader.BlockState
+	fallbacks := make(map[string]uint32, len(entries))
+	for _, entry := range entries {
+		for _, properties := range blockPermutations(entry.Properties) {
+			customStates = append(customStates, blockupgrader.BlockState{
+				Name:       entry.Name,
+				Properties: properties,
+				Version:    legacychunk.CurrentBlockVersion,
+			})
+		}
+		fallback := guessBlockFallback(entry.Properties)
+		properties, _ := latestmappings.DefaultProperties(fallback)
+		fallbacks[entry.Name] = legacymappings.StateToRuntimeID(fallback, properties)
+	}
+	s.palette.Store(latestmappings.NewPalette(customStates))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blockFallbacks = fallbacks
+}
+
+// latestPalette returns the block palette of the server of the session. It is the vanilla palette unless the
+// server has custom blocks.
+func (s *session) latestPalette() *latestmappings.Palette {
+	if p := s.palette.Load(); p != nil {
+		return p
+	}
+	return latestmappings.VanillaPalette()
+}
+
+// LatestAirRuntimeID returns the runtime ID of air used by the server that the connection passed is connected to.
+// It differs from the vanilla runtime ID if the server has custom blocks.
+func LatestAirRuntimeID(conn *minecraft.Conn) uint32 {
+	return sessionFor(conn).latestPalette().AirRuntimeID()
+}
+
+// blockPermutations returns every combination of the values of the properties of a custom block. A block without
+// properties has a single permutation without properties.
+func blockPermutations(m map[string]any) []map[string]any {
+	permutations := []map[string]any{{}}
+	properties, _ := m["properties"].([]any)
+	for _, p := range properties {
+		property, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := property["name"].(string)
+		values, _ := property["enum"].([]any)
+		if len(values) == 0 {
+			continue
+		}
+		next := make([]map[string]any, 0, len(permutations)*len(values))
+		for _, permutation := range permutations {
+			for _, v := range values {
+				combined := make(map[string]any, len(permutation)+1)
+				for k, v := range permutation {
+					combined[k] = v
+				}
+				combined[name] = v
+				next = append(next, combined)
+			}
+		}
+		permutations = next
+	}
+	return permutations
+}
+
+// guessBlockFallback guesses the vanilla block that looks most like a custom block, based on the components of
+// the custom block.
+func guessBlockFallback(m map[string]any) string {
+	components, _ := m["components"].(map[string]any)
+	if geometry, ok := components["minecraft:geometry"].(map[string]any); ok {
+		if id, _ := geometry["identifier"].(string); strings.HasSuffix(id, "geometry.cross") {
+			return "minecraft:short_grass"
+		}
+	}
+	if collision, ok := components["minecraft:collision_box"].(map[string]any); ok {
+		if enabled, ok := collision["enabled"].(uint8); ok && enabled == 0 {
+			return "minecraft:short_grass"
+		}
+		if size, ok := collision["size"].([]any); ok && len(size) == 3 {
+			if height, ok := size[1].(float32); ok && height <= 8 {
+				return "minecraft:smooth_stone_slab"
+			}
+		}
+	}
+	if light, ok := components["minecraft:light_emission"].(map[string]any); ok {
+		if emission, _ := light["emission"].(uint8); emission > 0 {
+			return "minecraft:glowstone"
+		}
+	}
+	if materials, ok := components["minecraft:material_instances"].(map[string]any); ok {
+		instances, _ := materials["materials"].(map[string]any)
+		if instance, ok := instances["*"].(map[string]any); ok {
+			switch instance["render_method"] {
+			case "blend", "alpha_test":
+				return "minecraft:glass"
+			}
+		}
+	}
+	return "minecraft:stone"
+}
+
+// customBlockFallbacks returns the runtime IDs of the legacy blocks shown in place of custom blocks, indexed by the
+// name of the custom block. The map returned must not be modified.
+func (s *session) customBlockFallbacks() map[string]uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blockFallbacks
+}
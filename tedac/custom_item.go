@@ -0,0 +1,167 @@
+package tedac
+
+import (
+	"strings"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+)
+
+// customItemTag is the key of the NBT tag added to the fallbacks of custom items. It holds the runtime ID of the
+// custom item, so that the item can be upgraded back to the custom item when the client sends it.
+const customItemTag = "tedac:custom_item"
+
+// customItem is an item defined by the server that does not exist in vanilla. It is shown to the client as a
+// vanilla fallback item carrying the name of the custom item.
+type customItem struct {
+	// name is the name of the custom item, such as 'example:katana'.
+	name string
+	// fallback is the legacy item shown in place of the custom item.
+	fallback legacyItemType
+	// displayName is the name shown to the client for the custom item, unless the item has a custom name.
+	displayName string
+}
+
+// registerCustomItems registers all items in the item registry passed that don't exist in vanilla as custom
+// items of the session.
+func (s *session) registerCustomItems(entries []protocol.ItemEntry) {
+	items := make(map[int32]customItem)
+	for _, entry := range entries {
+		if _, ok := latestmappings.ItemNameToRuntimeID(entry.Name); ok {
+			continue
+		}
+		fallback, ok := itemFallback(entry.Name)
+		if !ok {
+			fallback.id, _ = legacymappings.ItemIDByName(guessItemFallback(entry.Data))
+		}
+		items[int32(entry.RuntimeID)] = customItem{
+			name:        entry.Name,
+			fallback:    fallback,
+			displayName: customItemDisplayName(entry.Name, entry.Data),
+		}
+	}
+
+	s.customItemsMu.Lock()
+	defer s.customItemsMu.Unlock()
+	s.customItems = items
+}
+
+// customItem returns the custom item with the runtime ID passed. False is returned if the runtime ID does not
+// belong to a custom item.
+func (s *session) customItem(runtimeID int32) (customItem, bool) {
+	s.customItemsMu.RLock()
+	defer s.customItemsMu.RUnlock()
+	item, ok := s.customItems[runtimeID]
+	return item, ok
+}
+
+// downgradeCustomItem downgrades a custom item to its fallback. The display name and runtime ID of the custom
+// item are stored in the NBT of the fallback.
+func downgradeCustomItem(c customItem, input protocol.ItemStack) legacyprotocol.ItemStack {
+	nbtData := downgradeItemNBT(input.NBTData)
+	if len(nbtData) == 0 {
+		nbtData = make(map[string]any, 2)
+	}
+	if display, _ := nbtData["display"].(map[string]any); display["Name"] == nil {
+		named := make(map[string]any, len(display)+1)
+		for k, v := range display {
+			named[k] = v
+		}
+		named["Name"] = c.displayName
+		nbtData["display"] = named
+	}
+	nbtData[customItemTag] = input.NetworkID
+	return legacyprotocol.ItemStack{
+		ItemType:      legacyprotocol.ItemType{NetworkID: int32(c.fallback.id), MetadataValue: c.fallback.meta},
+		Count:         int16(input.Count),
+		NBTData:       nbtData,
+		CanBePlacedOn: input.CanBePlacedOn,
+		CanBreak:      input.CanBreak,
+	}
+}
+
+// upgradeCustomItem upgrades the fallback of a custom item sent by the client back to the custom item. False is
+// returned if the item passed is not the fallback of a custom item.
+func (s *session) upgradeCustomItem(input legacyprotocol.ItemStack) (protocol.ItemStack, bool) {
+	runtimeID, ok := input.NBTData[customItemTag].(int32)
+	if !ok {
+		return protocol.ItemStack{}, false
+	}
+	c, ok := s.customItem(runtimeID)
+	if !ok {
+		return protocol.ItemStack{}, false
+	}
+	nbtData := upgradeItemNBT(withoutKey(input.NBTData, customItemTag))
+	if display, ok := nbtData["display"].(map[string]any); ok && display["Name"] == c.displayName {
+		if display = withoutKey(display, "Name"); len(display) == 0 {
+			delete(nbtData, "display")
+		} else {
+			nbtData["display"] = display
+		}
+	}
+	return protocol.ItemStack{
+		ItemType:      protocol.ItemType{NetworkID: runtimeID},
+		Count:         uint16(input.Count),
+		NBTData:       nbtData,
+		CanBePlacedOn: input.CanBePlacedOn,
+		CanBreak:      input.CanBreak,
+	}, true
+}
+
+// customItemDisplayName returns the name shown to the client for the custom item with the name and item data
+// passed. If the item has no display name, a name is made up from the identifier of the item.
+func customItemDisplayName(name string, data map[string]any) string {
+	components, _ := data["components"].(map[string]any)
+	if displayName, ok := components["minecraft:display_name"].(map[string]any); ok {
+		if value, _ := displayName["value"].(string); value != "" {
+			return "§r" + downgradeText(value)
+		}
+	}
+	return "§r" + identifierDisplayName(name)
+}
+
+// identifierDisplayName makes up a readable name from an identifier, such as 'Katana' for 'example:katana'.
+func identifierDisplayName(identifier string) string {
+	if i := strings.IndexByte(identifier, ':'); i != -1 {
+		identifier = identifier[i+1:]
+	}
+	words := strings.Fields(strings.ReplaceAll(identifier, "_", " "))
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// guessItemFallback guesses the vanilla item that is used most like a custom item, based on the components of
+// the custom item.
+func guessItemFallback(data map[string]any) string {
+	components, _ := data["components"].(map[string]any)
+	if wearable, ok := components["minecraft:wearable"].(map[string]any); ok {
+		switch wearable["slot"] {
+		case "slot.armor.head":
+			return "minecraft:iron_helmet"
+		case "slot.armor.chest":
+			return "minecraft:iron_chestplate"
+		case "slot.armor.legs":
+			return "minecraft:iron_leggings"
+		case "slot.armor.feet":
+			return "minecraft:iron_boots"
+		}
+	}
+	if _, ok := components["minecraft:food"]; ok {
+		return "minecraft:apple"
+	}
+	if _, ok := components["minecraft:throwable"]; ok {
+		return "minecraft:snowball"
+	}
+	if _, ok := components["minecraft:block_placer"]; ok {
+		return "minecraft:stone"
+	}
+	properties, _ := components["item_properties"].(map[string]any)
+	if _, ok := components["minecraft:damage"]; ok || properties["hand_equipped"] == uint8(1) {
+		return "minecraft:iron_sword"
+	}
+	return "minecraft:paper"
+}
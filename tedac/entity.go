@@ -0,0 +1,124 @@
+package tedac
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+)
+
+// defaultEntityFallback is the legacy entity shown in place of entities that have no fallback, such as custom
+// entities. It is shown with a name tag made up from the identifier of the entity.
+var defaultEntityFallback = legacymappings.EntityFallback{Type: "minecraft:armor_stand"}
+
+// downgradeEntityType returns the legacy entity shown in place of the entity with the identifier passed. The
+// fallback configured by the user is used if present.
+func downgradeEntityType(identifier string) legacymappings.EntityFallback {
+	if f, ok := entityFallback(identifier); ok {
+		return f
+	}
+	if f, ok := legacymappings.DowngradeEntity(identifier); ok {
+		return f
+	}
+	f := defaultEntityFallback
+	f.NameTag = identifierDisplayName(identifier)
+	return f
+}
+
+// addEntity returns the legacy entity type and the metadata that an entity spawned by the server is shown with.
+// Entities shown as a fallback are tracked, so that the fallback is applied to metadata sent for them later on.
+func (s *session) addEntity(runtimeID uint64, uniqueID int64, identifier string, metadata map[uint32]any) (string, map[uint32]any) {
+	f := downgradeEntityType(identifier)
+	if f.Type == identifier {
+		return identifier, metadata
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entityFallbacks == nil {
+		s.entityFallbacks, s.entityRuntimeIDs = make(map[uint64]legacymappings.EntityFallback), make(map[int64]uint64)
+	}
+	s.entityFallbacks[runtimeID], s.entityRuntimeIDs[uniqueID] = f, runtimeID
+	return f.Type, applyEntityFallback(metadata, f, true)
+}
+
+// entityMetadata returns the metadata that the entity with the runtime ID passed is shown with, applying the
+// fallback of the entity if it has one.
+func (s *session) entityMetadata(runtimeID uint64, metadata map[uint32]any) map[uint32]any {
+	s.mu.Lock()
+	f, ok := s.entityFallbacks[runtimeID]
+	s.mu.Unlock()
+	if !ok {
+		return metadata
+	}
+	return applyEntityFallback(metadata, f, false)
+}
+
+// removeEntity stops tracking the entity with the unique ID passed.
+func (s *session) removeEntity(uniqueID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if runtimeID, ok := s.entityRuntimeIDs[uniqueID]; ok {
+		delete(s.entityFallbacks, runtimeID)
+		delete(s.entityRuntimeIDs, uniqueID)
+	}
+}
+
+// applyEntityFallback returns a copy of the entity metadata passed with the scale and name tag of the fallback
+// applied. The name tag is only shown if the entity has no name itself. If spawn is true, the metadata is that of
+// an entity being spawned, so that keys left out have their default values. Otherwise, keys left out are not
+// changed by the metadata, so the fallback is not applied to them.
+func applyEntityFallback(metadata map[uint32]any, f legacymappings.EntityFallback, spawn bool) map[uint32]any {
+	m := make(map[uint32]any, len(metadata)+3)
+	for k, v := range metadata {
+		m[k] = v
+	}
+	if spawn {
+		if _, ok := m[protocol.EntityDataKeyScale]; !ok {
+			m[protocol.EntityDataKeyScale] = float32(1)
+		}
+		if _, ok := m[protocol.EntityDataKeyName]; !ok {
+			m[protocol.EntityDataKeyName] = ""
+		}
+	}
+	if f.Scale != 0 {
+		if scale, ok := m[protocol.EntityDataKeyScale].(float32); ok {
+			m[protocol.EntityDataKeyScale] = scale * f.Scale
+		}
+	}
+	if f.NameTag != "" {
+		if name, ok := m[protocol.EntityDataKeyName].(string); ok && name == "" {
+			m[protocol.EntityDataKeyName] = f.NameTag
+			m[protocol.EntityDataKeyAlwaysShowNameTag] = uint8(1)
+		}
+	}
+	return m
+}
+
+// downgradeActorIdentifiers downgrades the serialised entity identifiers of an AvailableActorIdentifiers packet.
+// Entities that don't exist in v1.12.0 are left out, as the client shows them as their fallbacks.
+func downgradeActorIdentifiers(data []byte) []byte {
+	var identifiers struct {
+		IDList []map[string]any `nbt:"idlist"`
+	}
+	if err := nbt.UnmarshalEncoding(data, &identifiers, nbt.NetworkLittleEndian); err != nil {
+		return data
+	}
+	idList := make([]map[string]any, 0, len(identifiers.IDList))
+	for _, entry := range identifiers.IDList {
+		identifier, _ := entry["id"].(string)
+		id, ok := legacymappings.EntityID(identifier)
+		if !ok {
+			continue
+		}
+		entry = withoutKey(entry, "rid")
+		entry["rid"] = id
+		idList = append(idList, entry)
+	}
+	identifiers.IDList = idList
+
+	downgraded, err := nbt.MarshalEncoding(identifiers, nbt.NetworkLittleEndian)
+	if err != nil {
+		return data
+	}
+	return downgraded
+}
@@ -0,0 +1,222 @@
+package tedac
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+)
+
+// Fallbacks holds the legacy blocks, items and entities that are shown to v1.12.0 clients in place of the ones of
+// the latest version. They take precedence over the mappings and fallbacks that Tedac chooses itself, and may be
+// used for both vanilla and custom content. Fallbacks are usually read from a JSON file using LoadFallbacks.
+type Fallbacks struct {
+	// Blocks holds the fallbacks for block states of the latest version. If multiple fallbacks match the same
+	// state, the first one is used.
+	Blocks []BlockFallback `json:"blocks"`
+	// Items holds the fallbacks for items of the latest version, indexed by the name of the item, such as
+	// 'minecraft:echo_shard'.
+	Items map[string]LegacyItem `json:"items"`
+	// Entities holds the fallbacks for entities of the latest version, indexed by the identifier of the entity,
+	// such as 'minecraft:warden'.
+	Entities map[string]LegacyEntity `json:"entities"`
+}
+
+// BlockFallback is a fallback for one or more block states of the latest version.
+type BlockFallback struct {
+	// Name is the name of the block in the latest version, such as 'minecraft:deepslate'.
+	Name string `json:"name"`
+	// Properties holds the properties that a block state must have for the fallback to be used. Properties that
+	// are left out, or that have the value '*', match any value.
+	Properties map[string]any `json:"properties,omitempty"`
+	// Fallback is the legacy block shown in place of the matching block states.
+	Fallback LegacyBlock `json:"fallback"`
+}
+
+// LegacyBlock is a block as it existed in v1.12.0, such as 'minecraft:stone' with metadata value 1 for granite.
+type LegacyBlock struct {
+	Name string `json:"name"`
+	Meta int16  `json:"meta"`
+}
+
+// LegacyItem is an item as it existed in v1.12.0, such as 'minecraft:dye' with metadata value 4 for lapis lazuli.
+type LegacyItem struct {
+	Name string `json:"name"`
+	Meta int16  `json:"meta"`
+}
+
+// LegacyEntity is an entity as it existed in v1.12.0, such as 'minecraft:wolf', shown in place of a newer entity.
+type LegacyEntity struct {
+	Type string `json:"type"`
+	// Scale is the scale that the legacy entity is shown with, relative to the scale of the newer entity. If
+	// zero, the scale is left unchanged.
+	Scale float32 `json:"scale,omitempty"`
+	// NameTag is the name tag shown above the legacy entity if the newer entity has no name itself. If empty, no
+	// name tag is shown.
+	NameTag string `json:"name_tag,omitempty"`
+}
+
+// fallbackSet holds the fallbacks currently in use, resolved to legacy runtime IDs and item IDs.
+type fallbackSet struct {
+	// blocks holds the block fallbacks, indexed by the name of the block in the latest version.
+	blocks map[string][]blockFallback
+	// items holds the item fallbacks, indexed by the name of the item in the latest version.
+	items map[string]legacyItemType
+	// entities holds the entity fallbacks, indexed by the identifier of the entity in the latest version.
+	entities map[string]legacymappings.EntityFallback
+}
+
+// blockFallback is a BlockFallback resolved to the runtime ID of the legacy block.
+type blockFallback struct {
+	properties map[string]any
+	runtimeID  uint32
+}
+
+// legacyItemType is a LegacyItem resolved to the ID of the legacy item.
+type legacyItemType struct {
+	id, meta int16
+}
+
+// fallbackItemTag is the key of the NBT tag added to items shown as a user fallback. It holds the runtime ID and
+// metadata value of the original item, so that the item can be upgraded back to it when the client sends it.
+const fallbackItemTag = "tedac:fallback_item"
+
+// userFallbacks holds the *fallbackSet currently in use. It is replaced as a whole when the fallbacks are changed.
+var userFallbacks atomic.Pointer[fallbackSet]
+
+// LoadFallbacks reads fallbacks from the JSON file at the path passed and starts using them, replacing any
+// fallbacks used before. If the file does not exist, no fallbacks are used.
+func LoadFallbacks(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return SetFallbacks(Fallbacks{})
+	} else if err != nil {
+		return fmt.Errorf("read fallbacks: %w", err)
+	}
+	var f Fallbacks
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("decode fallbacks: %w", err)
+	}
+	return SetFallbacks(f)
+}
+
+// SetFallbacks starts using the fallbacks passed, replacing any fallbacks used before. An error is returned if a
+// fallback refers to a block or item that does not exist in v1.12.0, in which case the fallbacks used before are
+// kept.
+func SetFallbacks(f Fallbacks) error {
+	set := &fallbackSet{
+		blocks:   make(map[string][]blockFallback, len(f.Blocks)),
+		items:    make(map[string]legacyItemType, len(f.Items)),
+		entities: make(map[string]legacymappings.EntityFallback, len(f.Entities)),
+	}
+	for _, b := range f.Blocks {
+		rid, ok := legacymappings.BlockRuntimeID(b.Fallback.Name, b.Fallback.Meta)
+		if !ok {
+			return fmt.Errorf("fallback for block %v: unknown legacy block %v:%v", b.Name, b.Fallback.Name, b.Fallback.Meta)
+		}
+		set.blocks[b.Name] = append(set.blocks[b.Name], blockFallback{properties: b.Properties, runtimeID: rid})
+	}
+	for name, i := range f.Items {
+		id, ok := legacymappings.ItemIDByName(i.Name)
+		if !ok {
+			return fmt.Errorf("fallback for item %v: unknown legacy item %v", name, i.Name)
+		}
+		set.items[name] = legacyItemType{id: id, meta: i.Meta}
+	}
+	for identifier, e := range f.Entities {
+		if _, ok := legacymappings.EntityID(e.Type); !ok {
+			return fmt.Errorf("fallback for entity %v: unknown legacy entity %v", identifier, e.Type)
+		}
+		set.entities[identifier] = legacymappings.EntityFallback{Type: e.Type, Scale: e.Scale, NameTag: e.NameTag}
+	}
+	userFallbacks.Store(set)
+	return nil
+}
+
+// blockFallbackFor returns the runtime ID of the legacy block configured as fallback for the block state passed.
+// False is returned if no fallback matches the state.
+func blockFallbackFor(name string, properties map[string]any) (uint32, bool) {
+	set := userFallbacks.Load()
+	if set == nil {
+		return 0, false
+	}
+	for _, f := range set.blocks[name] {
+		if propertiesMatch(f.properties, properties) {
+			return f.runtimeID, true
+		}
+	}
+	return 0, false
+}
+
+// itemFallback returns the legacy item configured as fallback for the item with the name passed. False is
+// returned if no fallback was configured for the item.
+func itemFallback(name string) (legacyItemType, bool) {
+	set := userFallbacks.Load()
+	if set == nil {
+		return legacyItemType{}, false
+	}
+	item, ok := set.items[name]
+	return item, ok
+}
+
+// withFallbackTag returns a copy of the NBT data passed with the fallback item tag holding the item type passed.
+func withFallbackTag(nbtData map[string]any, original protocol.ItemType) map[string]any {
+	tagged := withoutKey(nbtData, fallbackItemTag)
+	tagged[fallbackItemTag] = map[string]any{"id": original.NetworkID, "meta": int16(original.MetadataValue)}
+	return tagged
+}
+
+// fallbackOriginal returns the item type stored in the fallback item tag of the NBT data passed. False is returned
+// if the NBT data does not have the tag, meaning the item is not shown as a user fallback.
+func fallbackOriginal(nbtData map[string]any) (protocol.ItemType, bool) {
+	tag, ok := nbtData[fallbackItemTag].(map[string]any)
+	if !ok {
+		return protocol.ItemType{}, false
+	}
+	id, ok := tag["id"].(int32)
+	if !ok {
+		return protocol.ItemType{}, false
+	}
+	meta, _ := tag["meta"].(int16)
+	return protocol.ItemType{NetworkID: id, MetadataValue: uint32(meta)}, true
+}
+
+// entityFallback returns the legacy entity configured as fallback for the entity with the identifier passed. False
+// is returned if no fallback was configured for the entity.
+func entityFallback(identifier string) (legacymappings.EntityFallback, bool) {
+	set := userFallbacks.Load()
+	if set == nil {
+		return legacymappings.EntityFallback{}, false
+	}
+	e, ok := set.entities[identifier]
+	return e, ok
+}
+
+// propertiesMatch checks if the properties of a block state match the properties of a fallback. Values decoded
+// from JSON are compared by their textual representation, as their types differ from those of block states.
+func propertiesMatch(want, properties map[string]any) bool {
+	for k, v := range want {
+		if v == "*" {
+			continue
+		}
+		actual, ok := properties[k]
+		if !ok {
+			return false
+		}
+		if b, ok := v.(bool); ok {
+			// Boolean properties are stored as bytes in block states.
+			v = 0
+			if b {
+				v = 1
+			}
+		}
+		if fmt.Sprint(v) != fmt.Sprint(actual) {
+			return false
+		}
+	}
+	return true
+}
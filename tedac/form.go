@@ -0,0 +1,201 @@
+package tedac
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// maxPendingForms is the maximum amount of custom forms of which the removed elements are kept while waiting for
+// the client to respond to them.
+const maxPendingForms = 16
+
+// legacyTextureDirectories holds the texture directories of the v1.12.0 resource pack that form button images may
+// point to. Images with a path outside of these directories are not shown, as v1.12.0 does not have them.
+var legacyTextureDirectories = []string{"textures/ui/", "textures/items/", "textures/blocks/", "textures/gui/"}
+
+// downgradeForm downgrades the JSON of a form sent by the server to a form that v1.12.0 can show. Headers are shown
+// as labels and dividers are removed, after which the indices of the removed elements are kept to correct the
+// response of the client. The form is returned as is if it could not be decoded.
+func (s *session) downgradeForm(formID uint32, data []byte) []byte {
+	var form map[string]any
+	if err := json.Unmarshal(data, &form); err != nil {
+		return data
+	}
+	for _, key := range []string{"title", "content"} {
+		if text, ok := form[key].(string); ok {
+			form[key] = downgradeText(text)
+		}
+	}
+
+	var removed []int
+	switch form["type"] {
+	case "form":
+		downgradeMenuForm(form)
+	case "custom_form":
+		removed = downgradeCustomForm(form)
+	}
+	s.trackRemovedFormElements(formID, removed)
+
+	downgraded, err := json.Marshal(form)
+	if err != nil {
+		return data
+	}
+	return downgraded
+}
+
+// trackRemovedFormElements stores the indices of the elements removed from the form with the ID passed. Forms that
+// are resent with the same ID replace the removed elements of the previous form.
+func (s *session) trackRemovedFormElements(formID uint32, removed []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.removedFormElements == nil {
+		s.removedFormElements = make(map[uint32][]int)
+	}
+	if i := slices.Index(s.pendingForms, formID); i != -1 {
+		s.pendingForms = slices.Delete(s.pendingForms, i, i+1)
+		delete(s.removedFormElements, formID)
+	}
+	if len(removed) == 0 {
+		return
+	}
+	if len(s.pendingForms) >= maxPendingForms {
+		// The client does not respond to forms that are replaced by another form, so the oldest form is dropped
+		// once too many have piled up.
+		delete(s.removedFormElements, s.pendingForms[0])
+		s.pendingForms = s.pendingForms[1:]
+	}
+	s.removedFormElements[formID] = removed
+	s.pendingForms = append(s.pendingForms, formID)
+}
+
+// downgradeMenuForm downgrades a menu form to v1.12.0. Newer versions may mix labels, headers and dividers with the
+// buttons of the form, which v1.12.0 cannot show, so the text of these is added to the content of the form instead.
+func downgradeMenuForm(form map[string]any) {
+	buttons, _ := form["buttons"].([]any)
+	if elements, ok := form["elements"].([]any); ok {
+		content, _ := form["content"].(string)
+		for _, e := range elements {
+			element, ok := e.(map[string]any)
+			if !ok {
+				continue
+			}
+			text, _ := element["text"].(string)
+			switch element["type"] {
+			case "header":
+				content += "\n§l" + downgradeText(text) + "§r"
+			case "label":
+				content += "\n" + downgradeText(text)
+			case "divider":
+			default:
+				buttons = append(buttons, element)
+			}
+		}
+		form["content"] = strings.TrimPrefix(content, "\n")
+		delete(form, "elements")
+	}
+
+	for i, b := range buttons {
+		button, ok := b.(map[string]any)
+		if !ok {
+			continue
+		}
+		if text, ok := button["text"].(string); ok {
+			button["text"] = downgradeText(text)
+		}
+		delete(button, "type")
+		delete(button, "tooltip")
+		if image, ok := button["image"].(map[string]any); ok && !downgradeFormImage(image) {
+			delete(button, "image")
+		}
+		buttons[i] = button
+	}
+	if buttons == nil {
+		buttons = []any{}
+	}
+	form["buttons"] = buttons
+}
+
+// downgradeFormImage downgrades the image of a form button to v1.12.0. False is returned if the image cannot be shown
+// by v1.12.0, in which case it should be removed from the button.
+func downgradeFormImage(image map[string]any) bool {
+	data, _ := image["data"].(string)
+	if data == "" {
+		return false
+	}
+	if image["type"] != "path" {
+		return image["type"] == "url"
+	}
+	data = strings.TrimSuffix(strings.TrimPrefix(data, "/"), ".png")
+	if !slices.ContainsFunc(legacyTextureDirectories, func(dir string) bool {
+		return strings.HasPrefix(data, dir)
+	}) {
+		return false
+	}
+	image["data"] = data
+	return true
+}
+
+// downgradeCustomForm downgrades a custom form to v1.12.0. Headers are shown as labels and dividers are removed. The
+// indices of the removed elements are returned.
+func downgradeCustomForm(form map[string]any) []int {
+	elements, _ := form["content"].([]any)
+	delete(form, "submit")
+
+	var removed []int
+	downgraded := make([]any, 0, len(elements))
+	for i, e := range elements {
+		element, ok := e.(map[string]any)
+		if !ok {
+			// Elements that are not objects cannot be shown, so they are removed like dividers.
+			removed = append(removed, i)
+			continue
+		}
+		delete(element, "tooltip")
+		switch element["type"] {
+		case "divider":
+			removed = append(removed, i)
+			continue
+		case "header":
+			text, _ := element["text"].(string)
+			element["type"], element["text"] = "label", "§l"+text+"§r"
+		}
+		for _, key := range []string{"text", "placeholder"} {
+			if text, ok := element[key].(string); ok {
+				element[key] = downgradeText(text)
+			}
+		}
+		downgraded = append(downgraded, element)
+	}
+	form["content"] = downgraded
+	return removed
+}
+
+// upgradeFormResponse upgrades the response of the client to a form to the latest version. The client does not
+// respond with a value for the elements that were removed from the form, so a null value is added for each of them
+// to keep the indices of the other values as the server expects them.
+func (s *session) upgradeFormResponse(formID uint32, data []byte) []byte {
+	s.mu.Lock()
+	removed, ok := s.removedFormElements[formID]
+	if ok {
+		delete(s.removedFormElements, formID)
+		s.pendingForms = slices.DeleteFunc(s.pendingForms, func(id uint32) bool { return id == formID })
+	}
+	s.mu.Unlock()
+	if !ok {
+		return data
+	}
+
+	var response []any
+	if err := json.Unmarshal(data, &response); err != nil {
+		return data
+	}
+	for _, i := range removed {
+		response = slices.Insert(response, min(i, len(response)), nil)
+	}
+	upgraded, err := json.Marshal(response)
+	if err != nil {
+		return data
+	}
+	return upgraded
+}
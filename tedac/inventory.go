@@ -0,0 +1,224 @@
+package tedac
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol/legacypacket"
+)
+
+const (
+	// craftingGridSmallOffset is the slot in the UI window at which the 2x2 crafting grid of the inventory
+	// starts.
+	craftingGridSmallOffset = 28
+	// craftingGridLargeOffset is the slot in the UI window at which the 3x3 crafting grid of a crafting table
+	// starts.
+	craftingGridLargeOffset = 32
+	// createdOutputSlot is the slot in the UI window in which items are created, such as the result of a
+	// crafting recipe or an item picked from the creative inventory.
+	createdOutputSlot = 50
+)
+
+// windowSlot is a combination of a window ID and a slot in that window. It is used to index the stack network
+// IDs of the items the server sent.
+type windowSlot struct {
+	window uint32
+	slot   uint32
+}
+
+// slotChange is a change to a slot that the client predicted for an item stack request.
+type slotChange struct {
+	windowSlot
+	// item is the item that the client predicted to be in the slot after the request.
+	item legacyprotocol.ItemStack
+	// networkID is the stack network ID of the item in the slot before the request.
+	networkID int32
+}
+
+// trackContent stores the item instances sent in an InventoryContent packet, along with their downgraded items.
+func (s *session) trackContent(windowID uint32, content []protocol.ItemInstance, items []legacyprotocol.ItemStack) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for slot, instance := range content {
+		s.trackItem(windowSlot{window: windowID, slot: uint32(slot)}, instance.StackNetworkID, items[slot])
+	}
+}
+
+// trackSlot stores the item instance sent in an InventorySlot packet, along with its downgraded item.
+func (s *session) trackSlot(windowID, slot uint32, instance protocol.ItemInstance, item legacyprotocol.ItemStack) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trackItem(windowSlot{window: windowID, slot: slot}, instance.StackNetworkID, item)
+}
+
+// trackItem stores the stack network ID and the item in a slot as the server sent it. s.mu must be held when
+// calling this method.
+func (s *session) trackItem(slot windowSlot, networkID int32, item legacyprotocol.ItemStack) {
+	s.stackNetworkIDs[slot] = networkID
+	if item.NetworkID == 0 {
+		delete(s.items, slot)
+		return
+	}
+	s.items[slot] = item
+}
+
+// trackResponses updates the stack network IDs and items of all slots changed by the item stack responses passed.
+// The client already predicted the results of its requests, so for requests that the server rejected, packets that
+// resend the items the server holds in the slots changed by the request are returned.
+func (s *session) trackResponses(responses []protocol.ItemStackResponse) []packet.Packet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pks []packet.Packet
+	for _, response := range responses {
+		changes := s.pendingRequests[response.RequestID]
+		delete(s.pendingRequests, response.RequestID)
+		if response.Status != protocol.ItemStackResponseStatusOK {
+			pks = append(pks, s.rejectRequest(response.RequestID, changes)...)
+			continue
+		}
+		for _, c := range changes {
+			s.trackItem(c.windowSlot, s.stackNetworkIDs[c.windowSlot], c.item)
+		}
+		for _, info := range response.ContainerInfo {
+			windowID, ok := s.containerWindow(info.Container.ContainerID)
+			if !ok {
+				continue
+			}
+			for _, slotInfo := range info.SlotInfo {
+				slot := windowSlot{window: windowID, slot: uint32(slotInfo.Slot)}
+				item := s.items[slot]
+				if item.Count = int16(slotInfo.Count); item.Count == 0 {
+					item = legacyprotocol.ItemStack{}
+				}
+				s.trackItem(slot, slotInfo.StackNetworkID, item)
+			}
+		}
+	}
+	return pks
+}
+
+// rejectRequest reverts the changes the client predicted for an item stack request that the server rejected. The
+// stack network IDs of the slots changed are restored, and packets that show the client the items the server holds
+// in these slots are returned. s.mu must be held when calling this method.
+func (s *session) rejectRequest(requestID int32, changes []slotChange) []packet.Packet {
+	offset := s.craftingGridOffset()
+	pks := make([]packet.Packet, 0, len(changes))
+	for _, c := range changes {
+		if s.stackNetworkIDs[c.windowSlot] == requestID {
+			s.stackNetworkIDs[c.windowSlot] = c.networkID
+		}
+		item := s.items[c.windowSlot]
+		if c.window == legacyprotocol.WindowIDUI && c.slot >= offset && c.slot < createdOutputSlot {
+			if item.NetworkID == 0 {
+				delete(s.craftingGrid, c.slot-offset)
+			} else {
+				s.craftingGrid[c.slot-offset] = item
+			}
+		}
+		pks = append(pks, &legacypacket.InventorySlot{WindowID: c.window, Slot: c.slot, NewItem: item})
+	}
+	return pks
+}
+
+// trackContainer stores the window and type of the container opened by the ContainerOpen packet passed.
+func (s *session) trackContainer(pk *packet.ContainerOpen) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.openWindow, s.openContainerType = pk.WindowID, pk.ContainerType
+}
+
+// closeContainer clears the container tracked as opened by the client.
+func (s *session) closeContainer() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.openWindow, s.openContainerType = 0, 0
+}
+
+// craftingGridOffset returns the slot in the UI window at which the crafting grid currently used by the
+// client starts. s.mu must be held when calling this method.
+func (s *session) craftingGridOffset() uint32 {
+	if s.openContainerType == protocol.ContainerTypeWorkbench {
+		return craftingGridLargeOffset
+	}
+	return craftingGridSmallOffset
+}
+
+// containerWindow returns the legacy window ID that holds the container with the ID passed. False is returned
+// if the container is not part of any window known to the legacy client. s.mu must be held when calling this
+// method.
+func (s *session) containerWindow(containerID byte) (uint32, bool) {
+	switch containerID {
+	case protocol.ContainerHotBar, protocol.ContainerInventory, protocol.ContainerCombinedHotBarAndInventory:
+		return legacyprotocol.WindowIDInventory, true
+	case protocol.ContainerOffhand:
+		return legacyprotocol.WindowIDOffHand, true
+	case protocol.ContainerArmor:
+		return legacyprotocol.WindowIDArmour, true
+	case protocol.ContainerCursor, protocol.ContainerCraftingInput, protocol.ContainerCreatedOutput:
+		return legacyprotocol.WindowIDUI, true
+	case protocol.ContainerLevelEntity:
+		if s.openWindow != 0 {
+			return uint32(s.openWindow), true
+		}
+	}
+	return 0, false
+}
+
+// slotInfo returns the stack request slot info of the slot in the legacy window passed, including the stack
+// network ID of the item currently in it. s.mu must be held when calling this method.
+func (s *session) slotInfo(windowID int32, slot uint32) protocol.StackRequestSlotInfo {
+	var containerID byte
+	switch windowID {
+	case legacyprotocol.WindowIDInventory:
+		containerID = protocol.ContainerInventory
+		if slot < 9 {
+			containerID = protocol.ContainerHotBar
+		}
+	case legacyprotocol.WindowIDOffHand:
+		containerID = protocol.ContainerOffhand
+	case legacyprotocol.WindowIDArmour:
+		containerID = protocol.ContainerArmor
+	case legacyprotocol.WindowIDUI:
+		switch {
+		case slot == 0:
+			containerID = protocol.ContainerCursor
+		case slot == createdOutputSlot:
+			containerID = protocol.ContainerCreatedOutput
+		default:
+			containerID = protocol.ContainerCraftingInput
+		}
+	default:
+		containerID = protocol.ContainerLevelEntity
+	}
+	return protocol.StackRequestSlotInfo{
+		Container:      protocol.FullContainerName{ContainerID: containerID},
+		Slot:           byte(slot),
+		StackNetworkID: s.stackNetworkIDs[windowSlot{window: uint32(windowID), slot: slot}],
+	}
+}
+
+// stackRequest wraps the actions passed in a new item stack request. All slots passed are considered changed
+// by the request: Until the server responds, subsequent requests refer to the items in these slots using the
+// ID of this request. The changes are reverted if the server rejects the request. s.mu must be held when calling
+// this method.
+func (s *session) stackRequest(actions []protocol.StackRequestAction, changed ...slotChange) *packet.ItemStackRequest {
+	s.requestID -= 2
+	for i, c := range changed {
+		changed[i].networkID = s.stackNetworkIDs[c.windowSlot]
+		s.stackNetworkIDs[c.windowSlot] = s.requestID
+	}
+	s.pendingRequests[s.requestID] = changed
+	return &packet.ItemStackRequest{Requests: []protocol.ItemStackRequest{{
+		RequestID: s.requestID,
+		Actions:   actions,
+	}}}
+}
+
+// placeAction returns a place stack request action that moves count items from the source to the destination
+// slot passed.
+func placeAction(count byte, src, dst protocol.StackRequestSlotInfo) *protocol.PlaceStackRequestAction {
+	action := &protocol.PlaceStackRequestAction{}
+	action.Count, action.Source, action.Destination = count, src, dst
+	return action
+}
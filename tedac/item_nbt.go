@@ -0,0 +1,288 @@
+package tedac
+
+import (
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+)
+
+// maxLegacyEnchantmentID is the highest enchantment ID known to v1.12.0, which is the ID of Quick Charge.
+const maxLegacyEnchantmentID = 35
+
+// originalEnchantmentsTag is the key of the NBT tag that holds the enchantments of an item as the server sent them,
+// if any of them had to be replaced or removed for v1.12.0. They are restored when the client sends the item back.
+const originalEnchantmentsTag = "tedac:ench"
+
+// strippedTagsTag is the key of the NBT tag that holds the tags of an item that v1.12.0 does not handle, such as
+// those holding custom data of the server. They are restored when the client sends the item back.
+const strippedTagsTag = "tedac:stripped"
+
+// enchantmentEquivalents maps the IDs of enchantments added after v1.12.0 to the ID of the v1.12.0 enchantment with
+// the most similar effect. Enchantments without an equivalent, such as Swift Sneak, are removed.
+var enchantmentEquivalents = map[int16]int16{
+	// Soul Speed speeds up the wearer on soul sand, like Depth Strider does in water.
+	36: 7,
+	// Wind Burst launches the wielder, which comes closest to the launching of the target by Knockback.
+	38: 12,
+	// Density and Breach both raise the damage dealt, like Sharpness.
+	39: 9,
+	40: 9,
+}
+
+// legacyItemTags holds the keys of all item NBT tags that v1.12.0 handles. Other tags, such as those holding
+// custom data of the server, are not sent to the client.
+var legacyItemTags = map[string]struct{}{
+	"ench":           {},
+	"display":        {},
+	"Items":          {},
+	"RepairCost":     {},
+	"customColor":    {},
+	"Fireworks":      {},
+	"FireworksItem":  {},
+	"Patterns":       {},
+	"Base":           {},
+	"Type":           {},
+	"pages":          {},
+	"author":         {},
+	"title":          {},
+	"generation":     {},
+	"xuid":           {},
+	"map_uuid":       {},
+	"map_is_scaled":  {},
+	"map_scale":      {},
+	"Damage":         {},
+	"Unbreakable":    {},
+	"CanPlaceOn":     {},
+	"CanDestroy":     {},
+	"BlockEntityTag": {},
+}
+
+// downgradeItemNBT downgrades the NBT of an item to NBT that v1.12.0 understands. Enchantments unknown to
+// v1.12.0 are replaced with an equivalent or removed, display names and lore are normalised and the items held by
+// container items are downgraded. Tags that v1.12.0 does not handle are kept aside in the strippedTagsTag. The NBT
+// passed is left untouched.
+func downgradeItemNBT(m map[string]any) map[string]any {
+	if len(m) == 0 {
+		return m
+	}
+	downgraded := make(map[string]any, len(m))
+	stripped := make(map[string]any)
+	for k, v := range m {
+		if _, ok := legacyItemTags[k]; !ok {
+			stripped[k] = v
+			continue
+		}
+		switch k {
+		case "ench":
+			enchantments, ok := v.([]any)
+			if !ok {
+				stripped[k] = v
+				continue
+			}
+			legacyEnchantments, changed := downgradeEnchantments(enchantments)
+			if changed {
+				downgraded[originalEnchantmentsTag] = enchantments
+			}
+			if len(legacyEnchantments) == 0 {
+				continue
+			}
+			v = legacyEnchantments
+		case "display":
+			display, ok := downgradeDisplay(v)
+			if !ok {
+				stripped[k] = v
+				continue
+			}
+			v = display
+		case "Items":
+			v = convertNBTItems(v, downgradeNBTItem)
+		case "BlockEntityTag":
+			if tag, ok := v.(map[string]any); ok {
+				v = downgradeItemNBT(tag)
+			}
+		}
+		downgraded[k] = v
+	}
+	if len(stripped) > 0 {
+		downgraded[strippedTagsTag] = stripped
+	}
+	return downgraded
+}
+
+// upgradeItemNBT upgrades the NBT of an item sent by the client. Enchantments replaced or removed and tags stripped
+// when downgrading are restored, and the items held by container items are upgraded to the item format of the latest
+// version. The NBT passed is left untouched.
+func upgradeItemNBT(m map[string]any) map[string]any {
+	if len(m) == 0 {
+		return m
+	}
+	upgraded := make(map[string]any, len(m))
+	_, restore := m[originalEnchantmentsTag]
+	for k, v := range m {
+		switch k {
+		case "ench":
+			if restore {
+				continue
+			}
+		case originalEnchantmentsTag:
+			k = "ench"
+		case strippedTagsTag:
+			continue
+		case "Items":
+			v = convertNBTItems(v, upgradeNBTItem)
+		case "BlockEntityTag":
+			if tag, ok := v.(map[string]any); ok {
+				v = upgradeItemNBT(tag)
+			}
+		}
+		upgraded[k] = v
+	}
+	stripped, _ := m[strippedTagsTag].(map[string]any)
+	for k, v := range stripped {
+		if _, ok := upgraded[k]; !ok {
+			upgraded[k] = v
+		}
+	}
+	return upgraded
+}
+
+// downgradeEnchantments replaces the enchantments unknown to v1.12.0 with their equivalent, or removes them if they
+// have none. If multiple enchantments end up with the same ID, the highest level is kept. True is returned if any
+// enchantment was replaced or removed.
+func downgradeEnchantments(enchantments []any) ([]any, bool) {
+	legacyEnchantments := make([]any, 0, len(enchantments))
+	indices := make(map[int16]int, len(enchantments))
+	var changed bool
+	for _, e := range enchantments {
+		ench, ok := e.(map[string]any)
+		if !ok {
+			changed = true
+			continue
+		}
+		id, ok := ench["id"].(int16)
+		if !ok {
+			changed = true
+			continue
+		}
+		if id > maxLegacyEnchantmentID {
+			changed = true
+			if id, ok = enchantmentEquivalents[id]; !ok {
+				continue
+			}
+			ench = map[string]any{"id": id, "lvl": ench["lvl"]}
+		}
+		if i, ok := indices[id]; ok {
+			existing, _ := legacyEnchantments[i].(map[string]any)["lvl"].(int16)
+			if lvl, _ := ench["lvl"].(int16); lvl > existing {
+				legacyEnchantments[i] = ench
+			}
+			continue
+		}
+		indices[id] = len(legacyEnchantments)
+		legacyEnchantments = append(legacyEnchantments, ench)
+	}
+	return legacyEnchantments, changed
+}
+
+// downgradeDisplay normalises the display tag of an item. Names and lore that aren't strings are removed, and
+// formatting codes unknown to v1.12.0 are replaced. False is returned if nothing of the tag remains.
+func downgradeDisplay(v any) (map[string]any, bool) {
+	display, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	downgraded := make(map[string]any, 2)
+	if name, ok := display["Name"].(string); ok {
+		downgraded["Name"] = downgradeText(name)
+	}
+	if lore, ok := display["Lore"].([]any); ok {
+		lines := make([]any, 0, len(lore))
+		for _, l := range lore {
+			if line, ok := l.(string); ok {
+				lines = append(lines, downgradeText(line))
+			}
+		}
+		if len(lines) > 0 {
+			downgraded["Lore"] = lines
+		}
+	}
+	return downgraded, len(downgraded) > 0
+}
+
+// convertNBTItems converts every item in a list of NBT items, such as the contents of a shulker box, using the
+// function passed. Items that the function can't convert are removed from the list.
+func convertNBTItems(v any, f func(item map[string]any) (map[string]any, bool)) any {
+	items, ok := v.([]any)
+	if !ok {
+		return v
+	}
+	converted := make([]any, 0, len(items))
+	for _, i := range items {
+		item, ok := i.(map[string]any)
+		if !ok {
+			continue
+		}
+		if item, ok = f(item); ok {
+			converted = append(converted, item)
+		}
+	}
+	return converted
+}
+
+// downgradeNBTItem downgrades an item in the NBT format of the latest version, which refers to the item by its
+// name, to the v1.12.0 format, which refers to the item by its legacy ID.
+func downgradeNBTItem(item map[string]any) (map[string]any, bool) {
+	name, _ := item["Name"].(string)
+	if _, ok := latestmappings.ItemNameToRuntimeID(name); !ok {
+		return nil, false
+	}
+	damage, _ := item["Damage"].(int16)
+	id, meta, _ := legacymappings.DowngradeItem(name, damage)
+
+	downgraded := make(map[string]any, len(item))
+	for k, v := range item {
+		switch k {
+		case "Name", "Block":
+			continue
+		case "tag":
+			tag, _ := v.(map[string]any)
+			if d, ok := tag["Damage"].(int32); ok && legacymappings.Damageable(id) {
+				meta, tag = int16(d), withoutKey(tag, "Damage")
+			}
+			v = downgradeItemNBT(tag)
+		}
+		downgraded[k] = v
+	}
+	downgraded["id"], downgraded["Damage"] = id, meta
+	return downgraded, true
+}
+
+// upgradeNBTItem upgrades an item in the v1.12.0 NBT format, which refers to the item by its legacy ID, to the
+// format of the latest version, which refers to the item by its name.
+func upgradeNBTItem(item map[string]any) (map[string]any, bool) {
+	id, _ := item["id"].(int16)
+	damage, _ := item["Damage"].(int16)
+	name, meta, ok := legacymappings.UpgradeItem(id, damage)
+	if !ok {
+		return nil, false
+	}
+
+	upgraded := make(map[string]any, len(item))
+	for k, v := range item {
+		switch k {
+		case "id":
+			continue
+		case "tag":
+			tag, _ := v.(map[string]any)
+			v = upgradeItemNBT(tag)
+		}
+		upgraded[k] = v
+	}
+	if meta != 0 && legacymappings.Damageable(id) {
+		tag, _ := upgraded["tag"].(map[string]any)
+		tag = withoutKey(tag, "Damage")
+		tag["Damage"], meta = int32(meta), 0
+		upgraded["tag"] = tag
+	}
+	upgraded["Name"], upgraded["Damage"] = name, meta
+	return upgraded, true
+}
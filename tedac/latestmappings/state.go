@@ -4,6 +4,7 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"unsafe"
@@ -20,10 +21,8 @@ var (
 
 	// states holds a list of all possible vanilla block states.
 	states []blockupgrader.BlockState
-	// stateRuntimeIDs holds a map for looking up the runtime ID of a block by the stateHash it produces.
-	stateRuntimeIDs = map[StateHash]uint32{}
-	// runtimeIDToState holds a map for looking up the blockState of a block by its runtime ID.
-	runtimeIDToState = map[uint32]blockupgrader.BlockState{}
+	// vanillaPalette holds the runtime IDs of all vanilla block states, as used by servers without custom blocks.
+	vanillaPalette *Palette
 )
 
 var (
@@ -60,18 +59,35 @@ func init() {
 		if err := dec.Decode(&s); err != nil {
 			break
 		}
-
-		rid := uint32(len(states))
 		states = append(states, s)
-
-		stateRuntimeIDs[HashState(s)] = rid
-		runtimeIDToState[rid] = s
 	}
+	vanillaPalette = newPalette(states)
+}
+
+// Palette holds the runtime IDs of the block states of a server. Servers with custom blocks assign different
+// runtime IDs than vanilla servers, so each connection may have its own Palette. A Palette is never modified once
+// created, and is safe for concurrent use.
+type Palette struct {
+	// stateRuntimeIDs holds a map for looking up the runtime ID of a block by the stateHash it produces.
+	stateRuntimeIDs map[StateHash]uint32
+	// runtimeIDToState holds a map for looking up the blockState of a block by its runtime ID.
+	runtimeIDToState map[uint32]blockupgrader.BlockState
+	// airRuntimeID is the runtime ID of air in the palette.
+	airRuntimeID uint32
 }
 
-// Adjust adjusts the latest mappings to account for custom states.
-func Adjust(customStates []blockupgrader.BlockState) {
-	adjustedStates := append(states, customStates...)
+// VanillaPalette returns the Palette of a server without custom blocks.
+func VanillaPalette() *Palette {
+	return vanillaPalette
+}
+
+// NewPalette returns a Palette with the runtime IDs that a server with the custom block states passed assigns. The
+// vanilla Palette is returned if there are no custom block states.
+func NewPalette(customStates []blockupgrader.BlockState) *Palette {
+	if len(customStates) == 0 {
+		return vanillaPalette
+	}
+	adjustedStates := append(slices.Clone(states), customStates...)
 	sort.SliceStable(adjustedStates, func(i, j int) bool {
 		stateOne, stateTwo := adjustedStates[i], adjustedStates[j]
 		if stateOne.Name == stateTwo.Name {
@@ -79,18 +95,26 @@ func Adjust(customStates []blockupgrader.BlockState) {
 		}
 		return fnv1.HashString64(stateOne.Name) < fnv1.HashString64(stateTwo.Name)
 	})
+	return newPalette(adjustedStates)
+}
 
-	stateRuntimeIDs = make(map[StateHash]uint32, len(adjustedStates))
-	runtimeIDToState = make(map[uint32]blockupgrader.BlockState, len(adjustedStates))
-	for rid, state := range adjustedStates {
-		stateRuntimeIDs[HashState(state)] = uint32(rid)
-		runtimeIDToState[uint32(rid)] = state
+// newPalette creates a Palette that assigns runtime IDs to the states passed in order.
+func newPalette(states []blockupgrader.BlockState) *Palette {
+	p := &Palette{
+		stateRuntimeIDs:  make(map[StateHash]uint32, len(states)),
+		runtimeIDToState: make(map[uint32]blockupgrader.BlockState, len(states)),
 	}
+	for rid, state := range states {
+		p.stateRuntimeIDs[HashState(state)] = uint32(rid)
+		p.runtimeIDToState[uint32(rid)] = state
+	}
+	p.airRuntimeID, _ = p.StateToRuntimeID("minecraft:air", nil)
+	return p
 }
 
-// StateToRuntimeID converts a name and its state properties to a runtime ID.
-func StateToRuntimeID(name string, properties map[string]any) (runtimeID uint32, found bool) {
-	rid, ok := stateRuntimeIDs[HashState(blockupgrader.Upgrade(blockupgrader.BlockState{
+// StateToRuntimeID converts a name and its state properties to a runtime ID in the Palette.
+func (p *Palette) StateToRuntimeID(name string, properties map[string]any) (runtimeID uint32, found bool) {
+	rid, ok := p.stateRuntimeIDs[HashState(blockupgrader.Upgrade(blockupgrader.BlockState{
 		Name:       name,
 		Properties: properties,
 		Version:    legacychunk.CurrentBlockVersion,
@@ -98,10 +122,51 @@ func StateToRuntimeID(name string, properties map[string]any) (runtimeID uint32,
 	return rid, ok
 }
 
-// RuntimeIDToState converts a runtime ID to a name and its state properties.
+// RuntimeIDToState converts a runtime ID in the Palette to a name and its state properties.
+func (p *Palette) RuntimeIDToState(runtimeID uint32) (name string, properties map[string]any, found bool) {
+	s, ok := p.runtimeIDToState[runtimeID]
+	return s.Name, s.Properties, ok
+}
+
+// AirRuntimeID returns the runtime ID of air in the Palette.
+func (p *Palette) AirRuntimeID() uint32 {
+	return p.airRuntimeID
+}
+
+// StateToRuntimeID converts a name and its state properties to a runtime ID in the vanilla Palette.
+func StateToRuntimeID(name string, properties map[string]any) (runtimeID uint32, found bool) {
+	return vanillaPalette.StateToRuntimeID(name, properties)
+}
+
+// States returns all vanilla block states, in the order of their vanilla runtime IDs.
+func States() []blockupgrader.BlockState {
+	return states
+}
+
+// ItemNames returns the names of all vanilla items.
+func ItemNames() []string {
+	names := make([]string, 0, len(itemNamesToRuntimeIDs))
+	for name := range itemNamesToRuntimeIDs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultProperties returns the properties of the first vanilla state registered with the name passed. False is
+// returned if no vanilla block has the name passed.
+func DefaultProperties(name string) (properties map[string]any, found bool) {
+	for _, s := range states {
+		if s.Name == name {
+			return s.Properties, true
+		}
+	}
+	return nil, false
+}
+
+// RuntimeIDToState converts a runtime ID in the vanilla Palette to a name and its state properties.
 func RuntimeIDToState(runtimeID uint32) (name string, properties map[string]any, found bool) {
-	s := runtimeIDToState[runtimeID]
-	return s.Name, s.Properties, true
+	return vanillaPalette.RuntimeIDToState(runtimeID)
 }
 
 // ItemRuntimeIDToName converts an item runtime ID to a string ID.
@@ -107,13 +107,7 @@ func (storage *BlockStorage) resize(newPaletteSize paletteSize) {
 		return // Don't resize if the size is already equal.
 	}
 
-	const subChunkBlockCount = 16 * 16 * 16
-	requiredUint32s := subChunkBlockCount / int(uint32BitSize/newPaletteSize)
-	if newPaletteSize.padded() {
-		// Add one uint32 if the palette size is one of the padded sizes.
-		requiredUint32s++
-	}
-	n := make([]uint32, requiredUint32s)
+	n := make([]uint32, newPaletteSize.uint32s())
 
 	// Construct a new block storage, set all blocks in there manually. We can't easily do this in a better
 	// way, because all blocks will be at a different offset with a different length.
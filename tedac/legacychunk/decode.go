@@ -0,0 +1,92 @@
+package legacychunk
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// NetworkDecode decodes the network serialised data passed into a Chunk if successful. The count passed is the
+// amount of sub chunks present in the data. The border blocks and block entities that follow the biomes are
+// left in the buffer passed.
+func NetworkDecode(air uint32, buf *bytes.Buffer, count int) (*Chunk, error) {
+	if count > subChunkCount {
+		return nil, fmt.Errorf("sub chunk count %v exceeds maximum of %v", count, subChunkCount)
+	}
+	c := New(air)
+	for i := 0; i < count; i++ {
+		sub, err := DecodeSubChunk(air, buf, NetworkEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding sub chunk %v: %w", i, err)
+		}
+		c.sub[i] = sub
+	}
+	if _, err := buf.Read(c.biomes[:]); err != nil {
+		return nil, fmt.Errorf("error reading biomes: %w", err)
+	}
+	return c, nil
+}
+
+// DecodeSubChunk decodes a SubChunk from a bytes.Buffer. The Encoding passed defines how the block storages of the
+// SubChunk are decoded.
+func DecodeSubChunk(air uint32, buf *bytes.Buffer, e Encoding) (*SubChunk, error) {
+	ver, err := buf.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("error reading version: %w", err)
+	}
+	sub := NewSubChunk(air)
+	switch ver {
+	default:
+		return nil, fmt.Errorf("unknown sub chunk version %v: can't decode", ver)
+	case 1:
+		// Version 1 only has one layer for each sub chunk, but uses the format with palettes.
+		storage, err := decodeBlockStorage(buf, e)
+		if err != nil {
+			return nil, err
+		}
+		sub.storages = append(sub.storages, storage)
+	case 8:
+		// Version 8 allows up to 256 layers for one sub chunk.
+		storageCount, err := buf.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("error reading storage count: %w", err)
+		}
+		sub.storages = make([]*BlockStorage, storageCount)
+		for i := byte(0); i < storageCount; i++ {
+			sub.storages[i], err = decodeBlockStorage(buf, e)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sub, nil
+}
+
+// decodeBlockStorage decodes a BlockStorage from a bytes.Buffer. The Encoding passed is used to read either a
+// network or disk block storage.
+func decodeBlockStorage(buf *bytes.Buffer, e Encoding) (*BlockStorage, error) {
+	blockSize, err := buf.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("error reading block size: %w", err)
+	}
+	size := paletteSize(blockSize >> 1)
+	if !size.valid() {
+		return nil, fmt.Errorf("invalid block storage size %v", size)
+	}
+
+	uint32Count := size.uint32s()
+	byteCount := uint32Count * uint32ByteSize
+	data := buf.Next(byteCount)
+	if len(data) != byteCount {
+		return nil, fmt.Errorf("cannot read block storage (size=%v): not enough block data present: expected %v bytes, got %v", size, byteCount, len(data))
+	}
+	uint32s := make([]uint32, uint32Count)
+	for i := 0; i < uint32Count; i++ {
+		// Explicitly don't use the binary package to greatly improve performance of reading the uint32s.
+		uint32s[i] = uint32(data[i*4]) | uint32(data[i*4+1])<<8 | uint32(data[i*4+2])<<16 | uint32(data[i*4+3])<<24
+	}
+	p, err := e.decodePalette(buf, size)
+	if err != nil {
+		return nil, err
+	}
+	return newBlockStorage(uint32s, p), nil
+}
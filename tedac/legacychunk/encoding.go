@@ -2,6 +2,7 @@ package legacychunk
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
@@ -49,5 +50,81 @@ func (networkEncoding) decodePalette(buf *bytes.Buffer, blockSize paletteSize) (
 		}
 		blocks[i] = uint32(temp)
 	}
-	return &Palette{blockRuntimeIDs: blocks, size: blockSize}, nil
+	return newPalette(blockSize, blocks), nil
+}
+
+// DiskEncoding is the Encoding used for storing a Chunk on disk in the format of v1.12.0 worlds. It writes the name
+// and metadata value of every block in the palette as little endian NBT. Blocks must be registered using
+// RegisterBlock before a Chunk holding them is encoded or decoded with DiskEncoding.
+var DiskEncoding diskEncoding
+
+// diskBlock is a block as it is stored in the palette of a sub chunk on disk.
+type diskBlock struct {
+	Name string `nbt:"name"`
+	Val  int16  `nbt:"val"`
+}
+
+var (
+	// diskBlocks holds the blocks registered using RegisterBlock, indexed by their runtime ID.
+	diskBlocks []diskBlock
+	// diskRuntimeIDs maps a block registered using RegisterBlock to its runtime ID.
+	diskRuntimeIDs = map[diskBlock]uint32{}
+)
+
+// RegisterBlock registers the block with the name and metadata value passed, so that it may be stored on disk.
+// Blocks must be registered in the order of their runtime IDs.
+func RegisterBlock(name string, val int16) {
+	b := diskBlock{Name: name, Val: val}
+	diskRuntimeIDs[b] = uint32(len(diskBlocks))
+	diskBlocks = append(diskBlocks, b)
+}
+
+// diskEncoding implements the Chunk encoding for writing to disk.
+type diskEncoding struct{}
+
+func (diskEncoding) network() byte          { return 0 }
+func (diskEncoding) encoding() nbt.Encoding { return nbt.LittleEndian }
+func (diskEncoding) data2D(c *Chunk) []byte {
+	// The 2D data on disk is composed of a height map of 256 int16s, followed by the biomes.
+	b := make([]byte, 512, 768)
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			binary.LittleEndian.PutUint16(b[int(columnOffset(x, z))*2:], uint16(c.HighestBlock(x, z)+1))
+		}
+	}
+	return append(b, c.biomes[:]...)
+}
+func (diskEncoding) encodePalette(buf *bytes.Buffer, p *Palette) {
+	_ = binary.Write(buf, binary.LittleEndian, uint32(p.Len()))
+	enc := nbt.NewEncoderWithEncoding(buf, nbt.LittleEndian)
+	for _, runtimeID := range p.blockRuntimeIDs {
+		var b diskBlock
+		if int(runtimeID) < len(diskBlocks) {
+			b = diskBlocks[runtimeID]
+		}
+		_ = enc.Encode(b)
+	}
+}
+func (diskEncoding) decodePalette(buf *bytes.Buffer, blockSize paletteSize) (*Palette, error) {
+	var paletteCount uint32
+	if err := binary.Read(buf, binary.LittleEndian, &paletteCount); err != nil {
+		return nil, fmt.Errorf("error reading palette entry count: %w", err)
+	}
+	if paletteCount == 0 || paletteCount > 4096 {
+		return nil, fmt.Errorf("invalid palette entry count %v", paletteCount)
+	}
+
+	blocks, dec := make([]uint32, paletteCount), nbt.NewDecoderWithEncoding(buf, nbt.LittleEndian)
+	for i := uint32(0); i < paletteCount; i++ {
+		var b diskBlock
+		if err := dec.Decode(&b); err != nil {
+			return nil, fmt.Errorf("error decoding palette entry: %w", err)
+		}
+		runtimeID, ok := diskRuntimeIDs[b]
+		if !ok {
+			return nil, fmt.Errorf("cannot get runtime ID of block %v:%v", b.Name, b.Val)
+		}
+		blocks[i] = runtimeID
+	}
+	return newPalette(blockSize, blocks), nil
 }
@@ -101,3 +101,18 @@ func (palette *Palette) increaseSize() {
 func (p paletteSize) padded() bool {
 	return p == 3 || p == 5 || p == 6
 }
+
+// uint32s returns the amount of uint32s needed to store the blocks of a sub chunk using the palette size.
+func (p paletteSize) uint32s() int {
+	count := 16 * 16 * 16 / int(uint32BitSize/p)
+	if p.padded() {
+		// Padded sizes have an additional uint32 for the blocks that don't fit.
+		count++
+	}
+	return count
+}
+
+// valid checks if the palette size is one of the sizes that block storages may have.
+func (p paletteSize) valid() bool {
+	return p != 0 && int(p) < len(offsets) && (p == 1 || offsets[p] != 0)
+}
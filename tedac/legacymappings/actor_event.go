@@ -0,0 +1,25 @@
+package legacymappings
+
+import (
+	_ "embed"
+)
+
+var (
+	//go:embed actor_event_map.json
+	actorEventData []byte
+	// actorEvents holds the actor events of v1.12.0, indexed by names such as 'feed', and the actor events sent in
+	// place of newer ones, such as drinking a potion in place of drinking milk.
+	actorEvents = loadIDMap[uint8](actorEventData, "actor event")
+)
+
+// DowngradeActorEvent converts the ID of an actor event in the latest version to the ID of the actor event sent in
+// v1.12.0. False is returned if the actor event is not known.
+func DowngradeActorEvent(id uint8) (uint8, bool) {
+	return actorEvents.downgrade(id)
+}
+
+// ActorEventID returns the ID of the v1.12.0 actor event with the name passed, such as 'feed'. False is returned if
+// no such actor event exists.
+func ActorEventID(name string) (uint8, bool) {
+	return actorEvents.id(name)
+}
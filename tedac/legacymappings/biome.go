@@ -0,0 +1,32 @@
+package legacymappings
+
+import (
+	_ "embed"
+)
+
+var (
+	//go:embed biome_map.json
+	biomeData []byte
+	// biomes holds the biomes of v1.12.0, indexed by names such as 'plains', and the biomes shown in place of the
+	// biomes added since, mostly those of the caves and cliffs and nether updates.
+	biomes = loadIDMap[uint32](biomeData, "biome")
+)
+
+// plainsBiomeID is the ID of the plains biome, which is shown in place of biomes without a fallback.
+const plainsBiomeID = 1
+
+// DowngradeBiome converts the ID of a biome in the latest version to the ID of the biome shown in v1.12.0. False is
+// returned if the biome is not known, in which case the ID of the plains biome is returned.
+func DowngradeBiome(id uint32) (uint8, bool) {
+	if legacyID, ok := biomes.downgrade(id); ok {
+		return uint8(legacyID), true
+	}
+	return plainsBiomeID, false
+}
+
+// BiomeID returns the ID of the v1.12.0 biome with the name passed, such as 'plains'. False is returned if no
+// such biome exists.
+func BiomeID(name string) (uint8, bool) {
+	id, ok := biomes.id(name)
+	return uint8(id), ok
+}
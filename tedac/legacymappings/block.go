@@ -2,8 +2,9 @@ package legacymappings
 
 import (
 	"bytes"
-	_ "embed"
+	"embed"
 	"encoding/json"
+	"io/fs"
 
 	"github.com/df-mc/worldupgrader/blockupgrader"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
@@ -15,8 +16,10 @@ import (
 var (
 	//go:embed block_id_map.json
 	blockIDData []byte
-	//go:embed 1.12.0_to_1.18.10_blockstate_map.bin
-	blockStateMap []byte
+	// blockStateMapFS holds the v1.12.0 block state map. Its name holds the version that it was last upgraded to by
+	// cmd/genmappings.
+	//go:embed 1.12.0_to_*_blockstate_map.bin
+	blockStateMapFS embed.FS
 
 	// blocks holds a list of all existing v in the game.
 	blocks []BlockEntry
@@ -34,6 +37,14 @@ func init() {
 		panic(err)
 	}
 
+	matches, _ := fs.Glob(blockStateMapFS, "*.bin")
+	if len(matches) != 1 {
+		panic("expected a single block state map")
+	}
+	blockStateMap, err := blockStateMapFS.ReadFile(matches[0])
+	if err != nil {
+		panic(err)
+	}
 	buf := protocol.NewReader(bytes.NewBuffer(blockStateMap), 0, false)
 	var length uint32
 	buf.Varuint32(&length)
@@ -61,6 +72,7 @@ func init() {
 				Data:     int16(meta),
 				LegacyID: legacyId,
 			})
+			legacychunk.RegisterBlock(legacyStringId, int16(meta))
 			stateToRuntimeID[latestmappings.HashState(latestBlockState)] = legacyRID
 			runtimeIDToState[legacyRID] = latestBlockState
 		}
@@ -80,6 +92,17 @@ func StateToRuntimeID(name string, properties map[string]any) uint32 {
 	return rid
 }
 
+// BlockRuntimeID returns the runtime ID of the legacy block with the name and metadata value passed. False is
+// returned if no such block exists.
+func BlockRuntimeID(name string, data int16) (uint32, bool) {
+	for rid, b := range blocks {
+		if b.Name == name && b.Data == data {
+			return uint32(rid), true
+		}
+	}
+	return 0, false
+}
+
 // RuntimeIDToState converts a runtime ID to a name and its state properties.
 func RuntimeIDToState(runtimeID uint32) (name string, properties map[string]any, found bool) {
 	s := runtimeIDToState[runtimeID]
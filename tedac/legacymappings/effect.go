@@ -0,0 +1,19 @@
+package legacymappings
+
+import (
+	_ "embed"
+)
+
+var (
+	//go:embed effect_map.json
+	effectData []byte
+	// effects holds the effects of v1.12.0 and the effects shown in place of newer ones, such as blindness in place
+	// of darkness.
+	effects = loadIDMap[int32](effectData, "effect")
+)
+
+// DowngradeEffect converts the ID of an effect in the latest version to the ID of the effect shown in v1.12.0. False
+// is returned if the effect is not known, in which case it should not be shown.
+func DowngradeEffect(id int32) (int32, bool) {
+	return effects.downgrade(id)
+}
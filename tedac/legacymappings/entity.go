@@ -0,0 +1,63 @@
+package legacymappings
+
+import (
+	_ "embed"
+	"encoding/json"
+)
+
+var (
+	//go:embed entity_map.json
+	entityData []byte
+
+	// entityIDs maps the identifier of an entity that exists in v1.12.0 to its legacy numerical ID.
+	entityIDs = map[string]int32{}
+	// entityFallbacks maps the identifier of an entity added after v1.12.0 to the legacy entity shown in its place.
+	entityFallbacks = map[string]EntityFallback{}
+)
+
+// EntityFallback is a legacy entity shown in place of an entity that does not exist in v1.12.0.
+type EntityFallback struct {
+	// Type is the identifier of the legacy entity, such as 'minecraft:wolf'.
+	Type string `json:"type"`
+	// Scale is the scale that the legacy entity is shown with, relative to the scale of the entity itself. If
+	// zero, the scale of the entity is left unchanged.
+	Scale float32 `json:"scale,omitempty"`
+	// NameTag is the name tag shown above the legacy entity if the entity has no name itself, so that it may be
+	// told apart from the legacy entity. If empty, no name tag is shown.
+	NameTag string `json:"name_tag,omitempty"`
+}
+
+// init reads the legacy entities and the fallbacks of newer entities from the resource JSON.
+func init() {
+	var m struct {
+		Legacy    map[string]int32          `json:"legacy"`
+		Fallbacks map[string]EntityFallback `json:"fallbacks"`
+	}
+	if err := json.Unmarshal(entityData, &m); err != nil {
+		panic(err)
+	}
+	entityIDs = m.Legacy
+	for identifier, f := range m.Fallbacks {
+		if _, ok := entityIDs[f.Type]; !ok {
+			panic("unknown fallback " + f.Type + " for entity " + identifier)
+		}
+		entityFallbacks[identifier] = f
+	}
+}
+
+// EntityID returns the legacy numerical ID of the entity with the identifier passed. False is returned if the
+// entity does not exist in v1.12.0.
+func EntityID(identifier string) (int32, bool) {
+	id, ok := entityIDs[identifier]
+	return id, ok
+}
+
+// DowngradeEntity returns the legacy entity shown in place of the entity with the identifier passed. Entities that
+// exist in v1.12.0 are returned as is. False is returned if the entity is not known.
+func DowngradeEntity(identifier string) (EntityFallback, bool) {
+	if _, ok := entityIDs[identifier]; ok {
+		return EntityFallback{Type: identifier}, true
+	}
+	f, ok := entityFallbacks[identifier]
+	return f, ok
+}
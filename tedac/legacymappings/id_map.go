@@ -0,0 +1,71 @@
+package legacymappings
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// idMap holds the IDs of a kind of entry that exists in v1.12.0, such as sounds or effects, along with the
+// fallbacks of entries of that kind that were added after v1.12.0. Entries that exist in v1.12.0 have the same ID
+// in the latest version.
+type idMap[T ~uint8 | ~int32 | ~uint32] struct {
+	// ids maps the name of an entry that exists in v1.12.0 to its ID.
+	ids map[string]T
+	// legacy holds the IDs of all entries that exist in v1.12.0.
+	legacy map[T]struct{}
+	// fallbacks maps the ID of an entry added after v1.12.0 to the ID of the entry used in its place.
+	fallbacks map[T]T
+}
+
+// loadIDMap reads an idMap from the resource JSON passed. The JSON holds the names and IDs of the v1.12.0 entries
+// under 'legacy', and the IDs and fallbacks of newer entries under 'latest'. Newer entries with an empty fallback
+// have no equivalent in v1.12.0. kind is the kind of entry, used to report fallbacks that don't exist in v1.12.0.
+func loadIDMap[T ~uint8 | ~int32 | ~uint32](data []byte, kind string) idMap[T] {
+	var m struct {
+		Legacy map[string]T `json:"legacy"`
+		Latest map[string]struct {
+			ID       T      `json:"id"`
+			Fallback string `json:"fallback"`
+		} `json:"latest"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		panic(fmt.Errorf("decode %v map: %w", kind, err))
+	}
+	idm := idMap[T]{ids: m.Legacy, legacy: make(map[T]struct{}, len(m.Legacy)), fallbacks: make(map[T]T, len(m.Latest))}
+	for _, id := range m.Legacy {
+		idm.legacy[id] = struct{}{}
+	}
+	for name, e := range m.Latest {
+		if e.Fallback == "" {
+			continue
+		}
+		id, ok := m.Legacy[e.Fallback]
+		if !ok {
+			panic(fmt.Sprintf("unknown fallback %v for %v %v", e.Fallback, kind, name))
+		}
+		idm.fallbacks[e.ID] = id
+	}
+	return idm
+}
+
+// downgrade converts the ID of an entry in the latest version to the ID of the entry used in v1.12.0. False is
+// returned if the entry neither exists in v1.12.0 nor has a fallback.
+func (m idMap[T]) downgrade(id T) (T, bool) {
+	if _, ok := m.legacy[id]; ok {
+		return id, true
+	}
+	fallback, ok := m.fallbacks[id]
+	return fallback, ok
+}
+
+// exists checks if an entry with the ID passed exists in v1.12.0.
+func (m idMap[T]) exists(id T) bool {
+	_, ok := m.legacy[id]
+	return ok
+}
+
+// id returns the ID of the v1.12.0 entry with the name passed. False is returned if no such entry exists.
+func (m idMap[T]) id(name string) (T, bool) {
+	id, ok := m.ids[name]
+	return id, ok
+}
@@ -8,6 +8,10 @@ import (
 var (
 	//go:embed item_id_map.json
 	itemIDData []byte
+	// itemAliasData holds the names that items had in v1.12.0, indexed by their name in the latest version. It is
+	// generated by cmd/genmappings from the item upgrade schemas of the game and should not be edited by hand.
+	//go:embed item_alias_map.json
+	itemAliasData []byte
 
 	// items holds a list of all existing items in the game.
 	items []ItemEntry
@@ -15,6 +19,11 @@ var (
 	itemIDsToNames = map[int16]string{}
 	// itemNamesToIDs holds a map to translate item string IDs to runtime IDs.
 	itemNamesToIDs = map[string]int16{}
+
+	// latestNamesToAliases maps the names of items in the latest version to the names they had in v1.12.0.
+	latestNamesToAliases = map[string]string{}
+	// aliasesToLatestNames maps the names of items in v1.12.0 to the names they have in the latest version.
+	aliasesToLatestNames = map[string]string{}
 )
 
 // init reads all item entries from the resource JSON, and sets the according values in the maps.
@@ -28,27 +37,33 @@ func init() {
 		itemNamesToIDs[name] = id
 		itemIDsToNames[id] = name
 	}
+
+	var aliases map[string]string
+	if err := json.Unmarshal(itemAliasData, &aliases); err != nil {
+		panic(err)
+	}
+	for latest, alias := range aliases {
+		latestNamesToAliases[latest] = alias
+		aliasesToLatestNames[alias] = latest
+	}
 }
 
-// ItemNameByID returns an item's name by its legacy ID.
+// ItemNameByID returns an item's name by its legacy ID. Items that were renamed since v1.12.0 are returned with
+// their name in the latest version.
 func ItemNameByID(id int16) (string, bool) {
 	name, ok := itemIDsToNames[id]
-
-	if name == "minecraft:netherstar" {
-		name = "minecraft:nether_star"
+	if latest, ok := aliasesToLatestNames[name]; ok {
+		name = latest
 	}
-	// TODO: Properly handle item aliases.
-
 	return name, ok
 }
 
-// ItemIDByName returns an item's ID by its name.
+// ItemIDByName returns an item's ID by its name. Items that were renamed since v1.12.0 may be passed with their
+// name in the latest version.
 func ItemIDByName(name string) (int16, bool) {
-	if name == "minecraft:nether_star" {
-		name = "minecraft:netherstar"
+	if alias, ok := latestNamesToAliases[name]; ok {
+		name = alias
 	}
-	// TODO: Properly handle item aliases.
-
 	id, ok := itemNamesToIDs[name]
 	if !ok {
 		id = itemNamesToIDs["minecraft:name_tag"]
@@ -0,0 +1,118 @@
+package legacymappings
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+)
+
+// MetadataWildcard is the metadata value used by recipes to match items of a type regardless of their metadata.
+const MetadataWildcard = 0x7fff
+
+var (
+	// itemMetaData holds the flattened and damageable items. It is generated by cmd/genmappings from the item upgrade
+	// schemas of the game and should not be edited by hand.
+	//go:embed item_meta_map.json
+	itemMetaData []byte
+
+	// flattenedItems maps the name of an item in the latest version to the legacy item and metadata value it was
+	// flattened from.
+	flattenedItems = map[string]legacyItem{}
+	// legacyItems maps a legacy item and metadata value to the name of the item in the latest version.
+	legacyItems = map[legacyItem]string{}
+	// damageableItems holds the legacy IDs of all items that store their damage in the metadata value.
+	damageableItems = map[int16]struct{}{}
+)
+
+// legacyItem is a combination of a legacy item ID and a metadata value.
+type legacyItem struct {
+	id, meta int16
+}
+
+// init reads the flattened and damageable items from the resource JSON. Items flattened from blocks that the
+// upgrade schemas don't list are derived from the block state mappings.
+func init() {
+	var m struct {
+		Flattened []struct {
+			Name       string `json:"name"`
+			LegacyName string `json:"legacy_name"`
+			Meta       int16  `json:"meta"`
+		} `json:"flattened"`
+		Damageable []string `json:"damageable"`
+	}
+	if err := json.Unmarshal(itemMetaData, &m); err != nil {
+		panic(err)
+	}
+	for _, f := range m.Flattened {
+		id, ok := itemNamesToIDs[f.LegacyName]
+		if !ok {
+			continue
+		}
+		registerFlattenedItem(f.Name, legacyItem{id: id, meta: f.Meta})
+	}
+	for _, name := range m.Damageable {
+		if id, ok := itemNamesToIDs[name]; ok {
+			damageableItems[id] = struct{}{}
+		}
+	}
+
+	// Blocks such as wool and planks used to be a single item with variants in the metadata value. Their latest
+	// block states carry the name of the item they were flattened to. The first legacy block entry holding a
+	// state with that name has the metadata value of the item. Items already in the JSON keep their mapping.
+	for rid, b := range blocks {
+		name := runtimeIDToState[uint32(rid)].Name
+		if _, ok := ItemIDByName(name); ok {
+			continue
+		}
+		if _, ok := latestmappings.ItemNameToRuntimeID(name); !ok {
+			continue
+		}
+		id := b.LegacyID
+		if id > 255 {
+			id = 255 - id
+		}
+		registerFlattenedItem(name, legacyItem{id: id, meta: b.Data})
+	}
+}
+
+// registerFlattenedItem registers an item of the latest version as flattened from the legacy item passed. If the
+// item or the legacy item was already registered, the existing mapping is kept.
+func registerFlattenedItem(name string, item legacyItem) {
+	if _, ok := flattenedItems[name]; !ok {
+		flattenedItems[name] = item
+	}
+	if _, ok := legacyItems[item]; !ok {
+		legacyItems[item] = name
+	}
+}
+
+// DowngradeItem converts the name and metadata value of an item in the latest version to the ID and metadata
+// value of the v1.12.0 item. Items without a v1.12.0 equivalent are converted to a fallback item, in which case
+// false is returned.
+func DowngradeItem(name string, meta int16) (id int16, legacyMeta int16, ok bool) {
+	if item, ok := flattenedItems[name]; ok {
+		return item.id, item.meta, true
+	}
+	id, ok = ItemIDByName(name)
+	return id, meta, ok
+}
+
+// UpgradeItem converts the ID and metadata value of a v1.12.0 item to the name and metadata value of the item in
+// the latest version.
+func UpgradeItem(id, meta int16) (name string, latestMeta int16, ok bool) {
+	if meta != MetadataWildcard {
+		if name, ok := legacyItems[legacyItem{id: id, meta: meta}]; ok {
+			return name, 0, true
+		}
+	}
+	name, ok = ItemNameByID(id)
+	return name, meta, ok
+}
+
+// Damageable checks if the v1.12.0 item with the ID passed stores its damage in the metadata value, rather than
+// in the Damage tag of its NBT.
+func Damageable(id int16) bool {
+	_, ok := damageableItems[id]
+	return ok
+}
@@ -0,0 +1,43 @@
+package legacymappings
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+)
+
+// TestVanillaItemsResolve checks that every vanilla item of the latest version resolves to a v1.12.0 item, rather
+// than to the fallback item. Items that were added after v1.12.0 are listed in testdata and are expected to fall
+// back.
+func TestVanillaItemsResolve(t *testing.T) {
+	f, err := os.Open("testdata/items_added_after_1.12.0.txt")
+	if err != nil {
+		t.Fatalf("open added items: %v", err)
+	}
+	defer f.Close()
+
+	added := make(map[string]struct{})
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if name := strings.TrimSpace(s.Text()); name != "" {
+			added[name] = struct{}{}
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("read added items: %v", err)
+	}
+
+	for _, name := range latestmappings.ItemNames() {
+		_, _, ok := DowngradeItem(name, 0)
+		_, listed := added[name]
+		switch {
+		case !ok && !listed:
+			t.Errorf("vanilla item %v resolves to the fallback item", name)
+		case ok && listed:
+			t.Errorf("vanilla item %v resolves, but is listed as added after v1.12.0", name)
+		}
+	}
+}
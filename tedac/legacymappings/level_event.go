@@ -0,0 +1,20 @@
+package legacymappings
+
+import (
+	_ "embed"
+)
+
+var (
+	//go:embed level_event_map.json
+	levelEventData []byte
+	// levelEvents holds the level events of v1.12.0, other than those that add a particle, and the level events sent
+	// in place of newer ones.
+	levelEvents = loadIDMap[int32](levelEventData, "level event")
+)
+
+// DowngradeLevelEvent converts the ID of a level event in the latest version to the ID of the level event sent in
+// v1.12.0. Level events that add a particle are not included. False is returned if the level event has no
+// equivalent in v1.12.0, in which case it should not be sent.
+func DowngradeLevelEvent(id int32) (int32, bool) {
+	return levelEvents.downgrade(id)
+}
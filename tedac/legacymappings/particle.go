@@ -0,0 +1,97 @@
+package legacymappings
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+)
+
+var (
+	//go:embed particle_map.json
+	particleData []byte
+
+	// particleIDs maps the name of a particle that exists in v1.12.0 to its legacy ID.
+	particleIDs = map[string]int32{}
+	// particles maps the ID of a particle in the latest version to the legacy ID of the particle shown in its place.
+	particles = map[int32]int32{}
+	// legacyParticleNames maps the legacy ID of a particle to its name.
+	legacyParticleNames = map[int32]string{}
+
+	// particleEffects holds the identifiers of all particle effects that exist in v1.12.0.
+	particleEffects = map[string]struct{}{}
+	// particleEffectFallbacks maps the identifier of a particle effect added after v1.12.0 to the identifier of the
+	// particle effect shown in its place.
+	particleEffectFallbacks = map[string]string{}
+)
+
+// init reads the particles and particle effects of v1.12.0 and the fallbacks of newer ones from the resource JSON.
+func init() {
+	var m struct {
+		Legacy map[string]int32 `json:"legacy"`
+		Latest map[string]struct {
+			ID       int32  `json:"id"`
+			Fallback string `json:"fallback"`
+		} `json:"latest"`
+		Effects struct {
+			Legacy    []string          `json:"legacy"`
+			Fallbacks map[string]string `json:"fallbacks"`
+		} `json:"effects"`
+	}
+	if err := json.Unmarshal(particleData, &m); err != nil {
+		panic(err)
+	}
+	particleIDs = m.Legacy
+	for name, id := range m.Legacy {
+		legacyParticleNames[id] = name
+	}
+	for name, p := range m.Latest {
+		if id, ok := particleIDs[name]; ok {
+			particles[p.ID] = id
+			continue
+		}
+		if p.Fallback == "" {
+			continue
+		}
+		id, ok := particleIDs[p.Fallback]
+		if !ok {
+			panic("unknown fallback " + p.Fallback + " for particle " + name)
+		}
+		particles[p.ID] = id
+	}
+
+	for _, identifier := range m.Effects.Legacy {
+		particleEffects[identifier] = struct{}{}
+	}
+	for identifier, fallback := range m.Effects.Fallbacks {
+		if _, ok := particleEffects[fallback]; !ok {
+			panic("unknown fallback " + fallback + " for particle effect " + identifier)
+		}
+		particleEffectFallbacks[identifier] = fallback
+	}
+}
+
+// DowngradeParticle converts the ID of a particle in the latest version to the ID of the particle shown in v1.12.0.
+// False is returned if the particle has no equivalent in v1.12.0, in which case it should not be shown.
+func DowngradeParticle(id int32) (int32, bool) {
+	legacyID, ok := particles[id]
+	return legacyID, ok
+}
+
+// ParticleName returns the name of the v1.12.0 particle with the legacy ID passed, such as 'icon_crack'.
+func ParticleName(id int32) string {
+	return legacyParticleNames[id]
+}
+
+// DowngradeParticleEffect returns the identifier of the particle effect shown in v1.12.0 in place of the particle
+// effect passed. Particle effects that are not vanilla, such as those of resource packs, are returned as is. False is
+// returned if a vanilla particle effect has no equivalent in v1.12.0, in which case it should not be shown.
+func DowngradeParticleEffect(identifier string) (string, bool) {
+	if !strings.HasPrefix(identifier, "minecraft:") {
+		return identifier, true
+	}
+	if _, ok := particleEffects[identifier]; ok {
+		return identifier, true
+	}
+	fallback, ok := particleEffectFallbacks[identifier]
+	return fallback, ok
+}
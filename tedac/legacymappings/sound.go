@@ -0,0 +1,25 @@
+package legacymappings
+
+import (
+	_ "embed"
+)
+
+var (
+	//go:embed sound_map.json
+	soundData []byte
+	// sounds holds the level sound events of v1.12.0 and the sounds played in place of newer ones. Many newer sounds,
+	// such as those of mobs added later, have nothing similar in v1.12.0 and are not played at all.
+	sounds = loadIDMap[uint32](soundData, "sound")
+)
+
+// DowngradeSound converts the ID of a level sound event in the latest version to the ID of the sound played in
+// v1.12.0. False is returned if the sound has no equivalent in v1.12.0, in which case it should not be played.
+func DowngradeSound(id uint32) (uint32, bool) {
+	return sounds.downgrade(id)
+}
+
+// UpgradeSound converts the ID of a level sound event in v1.12.0 to the ID of the sound in the latest version. False
+// is returned if the sound is not known.
+func UpgradeSound(id uint32) (uint32, bool) {
+	return id, sounds.exists(id)
+}
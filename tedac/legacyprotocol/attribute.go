@@ -28,3 +28,23 @@ func (x *Attribute) Marshal(r protocol.IO) {
 	r.Float32(&x.Default)
 	r.String(&x.Name)
 }
+
+// AttributeValue holds the value of an attribute of an entity being spawned, without the default value that is sent
+// when an attribute is updated.
+type AttributeValue struct {
+	// Name is the name of the attribute, for example 'minecraft:health'. These names must be identical to
+	// the ones defined client-side.
+	Name string
+	// Min and Max specify the boundaries within the value of the attribute must be.
+	Min, Max float32
+	// Value is the current value of the attribute.
+	Value float32
+}
+
+// Marshal encodes/decodes an AttributeValue.
+func (x *AttributeValue) Marshal(r protocol.IO) {
+	r.String(&x.Name)
+	r.Float32(&x.Min)
+	r.Float32(&x.Value)
+	r.Float32(&x.Max)
+}
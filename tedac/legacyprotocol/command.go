@@ -142,6 +142,14 @@ type CommandOrigin struct {
 	PlayerUniqueID int64
 }
 
+const (
+	CommandOutputTypeNone = iota
+	CommandOutputTypeLastOutput
+	CommandOutputTypeSilent
+	CommandOutputTypeAllOutput
+	CommandOutputTypeDataSet
+)
+
 // CommandOutputMessage represents a message sent by a command that holds the output of one of the commands
 // executed.
 type CommandOutputMessage struct {
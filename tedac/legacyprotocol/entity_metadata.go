@@ -3,242 +3,379 @@ package legacyprotocol
 import (
 	"math"
 
+	"github.com/go-gl/mathgl/mgl32"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
 )
 
-// DowngradeEntityMetadata downgrades entity metadata from latest version to legacy version.
-func DowngradeEntityMetadata(data map[uint32]any) map[uint32]any {
-	data = downgradeKey(data)
+// metadataType is the type of value that v1.12.0 expects for an entity metadata key.
+type metadataType byte
 
-	var flag1, flag2 int64
-	if v, ok := data[protocol.EntityDataKeyFlags]; ok {
-		flag1 = v.(int64)
+const (
+	metadataByte metadataType = iota
+	metadataShort
+	metadataInt
+	metadataFloat
+	metadataString
+	metadataCompound
+	metadataBlockPos
+	metadataLong
+	metadataVec3
+)
+
+// metadataKey is an entry of the entity metadata schema. It describes how the value of a key of the latest version
+// is translated to v1.12.0 and back.
+type metadataKey struct {
+	// legacyKey is the key of the value in v1.12.0.
+	legacyKey uint32
+	// typ is the type of the value in v1.12.0. Values are converted to this type when downgraded.
+	typ metadataType
+	// downgrade and upgrade, if not nil, convert the value itself, for values that mean something different in
+	// v1.12.0. Block runtime IDs in the value are those of the palette passed. False is returned if the value has no
+	// equivalent, in which case the key is left out.
+	downgrade, upgrade func(v any, palette *latestmappings.Palette) (any, bool)
+}
+
+// metadataSchema holds every entity metadata key of the latest version that exists in v1.12.0. Keys of the latest
+// version that are not in the schema are left out when downgrading.
+var metadataSchema = map[uint32]metadataKey{
+	protocol.EntityDataKeyFlags:                            {legacyKey: 0, typ: metadataLong},
+	protocol.EntityDataKeyStructuralIntegrity:              {legacyKey: 1, typ: metadataInt},
+	protocol.EntityDataKeyVariant:                          {legacyKey: 2, typ: metadataInt},
+	protocol.EntityDataKeyColorIndex:                       {legacyKey: 3, typ: metadataByte},
+	protocol.EntityDataKeyName:                             {legacyKey: 4, typ: metadataString},
+	protocol.EntityDataKeyOwner:                            {legacyKey: 5, typ: metadataLong},
+	protocol.EntityDataKeyTarget:                           {legacyKey: 6, typ: metadataLong},
+	protocol.EntityDataKeyAirSupply:                        {legacyKey: 7, typ: metadataShort},
+	protocol.EntityDataKeyEffectColor:                      {legacyKey: 8, typ: metadataInt},
+	protocol.EntityDataKeyEffectAmbience:                   {legacyKey: 9, typ: metadataByte},
+	protocol.EntityDataKeyJumpDuration:                     {legacyKey: 10, typ: metadataByte},
+	protocol.EntityDataKeyHurt:                             {legacyKey: 11, typ: metadataInt},
+	protocol.EntityDataKeyHurtDirection:                    {legacyKey: 12, typ: metadataInt},
+	protocol.EntityDataKeyRowTimeLeft:                      {legacyKey: 13, typ: metadataFloat},
+	protocol.EntityDataKeyRowTimeRight:                     {legacyKey: 14, typ: metadataFloat},
+	protocol.EntityDataKeyValue:                            {legacyKey: 15, typ: metadataInt},
+	protocol.EntityDataKeyDisplayTileRuntimeID:             {legacyKey: 16, typ: metadataInt, downgrade: downgradeDisplayBlock, upgrade: upgradeDisplayBlock},
+	protocol.EntityDataKeyDisplayOffset:                    {legacyKey: 17, typ: metadataInt},
+	protocol.EntityDataKeyCustomDisplay:                    {legacyKey: 18, typ: metadataByte},
+	protocol.EntityDataKeySwell:                            {legacyKey: 19, typ: metadataInt},
+	protocol.EntityDataKeyOldSwell:                         {legacyKey: 20, typ: metadataInt},
+	protocol.EntityDataKeySwellDirection:                   {legacyKey: 21, typ: metadataInt},
+	protocol.EntityDataKeyChargeAmount:                     {legacyKey: 22, typ: metadataByte},
+	protocol.EntityDataKeyCarryBlockRuntimeID:              {legacyKey: 23, typ: metadataShort, downgrade: downgradeCarriedBlock, upgrade: upgradeCarriedBlock},
+	protocol.EntityDataKeyPlayerFlags:                      {legacyKey: 26, typ: metadataByte},
+	protocol.EntityDataKeyPlayerIndex:                      {legacyKey: 27, typ: metadataInt},
+	protocol.EntityDataKeyBedPosition:                      {legacyKey: 28, typ: metadataBlockPos},
+	protocol.EntityDataKeyPowerX:                           {legacyKey: 29, typ: metadataFloat},
+	protocol.EntityDataKeyPowerY:                           {legacyKey: 30, typ: metadataFloat},
+	protocol.EntityDataKeyPowerZ:                           {legacyKey: 31, typ: metadataFloat},
+	protocol.EntityDataKeyAuxPower:                         {legacyKey: 32, typ: metadataInt},
+	protocol.EntityDataKeyFishX:                            {legacyKey: 33, typ: metadataFloat},
+	protocol.EntityDataKeyFishZ:                            {legacyKey: 34, typ: metadataFloat},
+	protocol.EntityDataKeyFishAngle:                        {legacyKey: 35, typ: metadataFloat},
+	protocol.EntityDataKeyAuxValueData:                     {legacyKey: 36, typ: metadataShort},
+	protocol.EntityDataKeyLeashHolder:                      {legacyKey: 37, typ: metadataLong},
+	protocol.EntityDataKeyScale:                            {legacyKey: 38, typ: metadataFloat},
+	protocol.EntityDataKeyAirSupplyMax:                     {legacyKey: 42, typ: metadataShort},
+	protocol.EntityDataKeyMarkVariant:                      {legacyKey: 43, typ: metadataInt},
+	protocol.EntityDataKeyContainerType:                    {legacyKey: 44, typ: metadataByte},
+	protocol.EntityDataKeyContainerSize:                    {legacyKey: 45, typ: metadataInt},
+	protocol.EntityDataKeyContainerStrengthModifier:        {legacyKey: 46, typ: metadataInt},
+	protocol.EntityDataKeyBlockTarget:                      {legacyKey: 47, typ: metadataBlockPos},
+	protocol.EntityDataKeyInventory:                        {legacyKey: 48, typ: metadataInt},
+	protocol.EntityDataKeyTargetA:                          {legacyKey: 49, typ: metadataLong},
+	protocol.EntityDataKeyTargetB:                          {legacyKey: 50, typ: metadataLong},
+	protocol.EntityDataKeyTargetC:                          {legacyKey: 51, typ: metadataLong},
+	protocol.EntityDataKeyAerialAttack:                     {legacyKey: 52, typ: metadataShort},
+	protocol.EntityDataKeyWidth:                            {legacyKey: 53, typ: metadataFloat},
+	protocol.EntityDataKeyHeight:                           {legacyKey: 54, typ: metadataFloat},
+	protocol.EntityDataKeyFuseTime:                         {legacyKey: 55, typ: metadataInt},
+	protocol.EntityDataKeySeatOffset:                       {legacyKey: 56, typ: metadataVec3},
+	protocol.EntityDataKeySeatLockPassengerRotation:        {legacyKey: 57, typ: metadataByte},
+	protocol.EntityDataKeySeatLockPassengerRotationDegrees: {legacyKey: 58, typ: metadataFloat},
+	protocol.EntityDataKeyDataRadius:                       {legacyKey: 60, typ: metadataFloat},
+	protocol.EntityDataKeyDataWaiting:                      {legacyKey: 61, typ: metadataInt},
+	protocol.EntityDataKeyDataParticle:                     {legacyKey: 62, typ: metadataInt},
+	protocol.EntityDataKeyPeekID:                           {legacyKey: 63, typ: metadataInt},
+	protocol.EntityDataKeyAttachFace:                       {legacyKey: 64, typ: metadataByte},
+	protocol.EntityDataKeyAttached:                         {legacyKey: 65, typ: metadataByte},
+	protocol.EntityDataKeyAttachedPosition:                 {legacyKey: 66, typ: metadataBlockPos},
+	protocol.EntityDataKeyTradeTarget:                      {legacyKey: 67, typ: metadataLong},
+	protocol.EntityDataKeyCareer:                           {legacyKey: 68, typ: metadataInt},
+	protocol.EntityDataKeyHasCommandBlock:                  {legacyKey: 69, typ: metadataByte},
+	protocol.EntityDataKeyCommandName:                      {legacyKey: 70, typ: metadataString},
+	protocol.EntityDataKeyLastCommandOutput:                {legacyKey: 71, typ: metadataString},
+	protocol.EntityDataKeyTrackCommandOutput:               {legacyKey: 72, typ: metadataByte},
+	protocol.EntityDataKeyControllingSeatIndex:             {legacyKey: 73, typ: metadataByte},
+	protocol.EntityDataKeyStrength:                         {legacyKey: 74, typ: metadataInt},
+	protocol.EntityDataKeyStrengthMax:                      {legacyKey: 75, typ: metadataInt},
+	protocol.EntityDataKeyDataSpellCastingColor:            {legacyKey: 76, typ: metadataInt},
+	protocol.EntityDataKeyDataLifetimeTicks:                {legacyKey: 77, typ: metadataInt},
+	protocol.EntityDataKeyPoseIndex:                        {legacyKey: 78, typ: metadataInt},
+	protocol.EntityDataKeyDataTickOffset:                   {legacyKey: 79, typ: metadataInt},
+	protocol.EntityDataKeyAlwaysShowNameTag:                {legacyKey: 80, typ: metadataByte},
+	protocol.EntityDataKeyColorTwoIndex:                    {legacyKey: 81, typ: metadataByte},
+	protocol.EntityDataKeyNameAuthor:                       {legacyKey: 82, typ: metadataString},
+	protocol.EntityDataKeyScore:                            {legacyKey: 83, typ: metadataString},
+	protocol.EntityDataKeyBalloonAnchor:                    {legacyKey: 84, typ: metadataLong},
+	protocol.EntityDataKeyPuffedState:                      {legacyKey: 85, typ: metadataByte},
+	protocol.EntityDataKeyBubbleTime:                       {legacyKey: 86, typ: metadataInt},
+	protocol.EntityDataKeyAgent:                            {legacyKey: 87, typ: metadataLong},
+	protocol.EntityDataKeySittingAmount:                    {legacyKey: 88, typ: metadataFloat},
+	protocol.EntityDataKeySittingAmountPrevious:            {legacyKey: 89, typ: metadataFloat},
+	protocol.EntityDataKeyEatingCounter:                    {legacyKey: 90, typ: metadataInt},
+	protocol.EntityDataKeyFlagsTwo:                         {legacyKey: 91, typ: metadataLong},
+	protocol.EntityDataKeyLayingAmount:                     {legacyKey: 92, typ: metadataFloat},
+	protocol.EntityDataKeyLayingAmountPrevious:             {legacyKey: 93, typ: metadataFloat},
+	protocol.EntityDataKeyDataDuration:                     {legacyKey: 94, typ: metadataInt},
+	protocol.EntityDataKeyDataSpawnTime:                    {legacyKey: 95, typ: metadataInt},
+	protocol.EntityDataKeyDataChangeRate:                   {legacyKey: 96, typ: metadataFloat},
+	protocol.EntityDataKeyDataChangeOnPickup:               {legacyKey: 97, typ: metadataFloat},
+	protocol.EntityDataKeyDataPickupCount:                  {legacyKey: 98, typ: metadataInt},
+	protocol.EntityDataKeyInteractText:                     {legacyKey: 99, typ: metadataString},
+	protocol.EntityDataKeyTradeTier:                        {legacyKey: 100, typ: metadataInt},
+	protocol.EntityDataKeyMaxTradeTier:                     {legacyKey: 101, typ: metadataInt},
+	protocol.EntityDataKeyTradeExperience:                  {legacyKey: 102, typ: metadataInt},
+	protocol.EntityDataKeySkinID:                           {legacyKey: 104, typ: metadataInt},
+	protocol.EntityDataKeyCommandBlockTickDelay:            {legacyKey: 105, typ: metadataInt},
+	protocol.EntityDataKeyCommandBlockExecuteOnFirstTick:   {legacyKey: 106, typ: metadataByte},
+	protocol.EntityDataKeyAmbientSoundInterval:             {legacyKey: 107, typ: metadataFloat},
+	protocol.EntityDataKeyAmbientSoundIntervalRange:        {legacyKey: 108, typ: metadataFloat},
+	protocol.EntityDataKeyAmbientSoundEventName:            {legacyKey: 109, typ: metadataString},
+}
+
+// droppedMetadataKeys holds the entity metadata keys of the latest version that are deliberately left out when
+// downgrading, because v1.12.0 uses the same key for a value that means something else. Any other key that is not
+// in metadataSchema is left out too, as it did not exist yet in v1.12.0.
+var droppedMetadataKeys = map[uint32]struct{}{
+	// v1.12.0 used these keys for the age and the held item of an entity.
+	protocol.EntityDataKeyClientEvent: {},
+	protocol.EntityDataKeyUsingItem:   {},
+	// v1.12.0 used these keys for the interactive tag, the skin and the URL tag of NPCs, which are now sent as
+	// JSON in different keys.
+	protocol.EntityDataKeyHasNPC:  {},
+	protocol.EntityDataKeyNPCData: {},
+	protocol.EntityDataKeyActions: {},
+	// v1.12.0 had a minimum rotation for riders, rather than a rotation offset.
+	protocol.EntityDataKeySeatRotationOffset:        {},
+	protocol.EntityDataKeySeatRotationOffsetDegrees: {},
+	// The spawning frames of an entity were added between the trade experience and the skin ID.
+	protocol.EntityDataKeySpawningFrames: {},
+}
+
+// legacyMetadataKeys maps a key of v1.12.0 to the key of the latest version.
+var legacyMetadataKeys = map[uint32]uint32{}
+
+// init fills out legacyMetadataKeys using the schema.
+func init() {
+	for key, k := range metadataSchema {
+		if _, ok := droppedMetadataKeys[key]; ok {
+			panic("entity metadata key is both in the schema and dropped")
+		}
+		legacyMetadataKeys[k.legacyKey] = key
+	}
+}
+
+// DowngradeEntityMetadata downgrades entity metadata from latest version to legacy version. Keys are renumbered,
+// values are converted to the types v1.12.0 expects and keys that did not exist in v1.12.0 are left out. Block
+// runtime IDs in the metadata are those of the palette passed.
+func DowngradeEntityMetadata(data map[uint32]any, palette *latestmappings.Palette) map[uint32]any {
+	newData := make(map[uint32]any, len(data))
+	for key, value := range data {
+		k, ok := metadataSchema[key]
+		if !ok {
+			continue
+		}
+		if k.downgrade != nil {
+			if value, ok = k.downgrade(value, palette); !ok {
+				continue
+			}
+		}
+		if value, ok = convertMetadataValue(value, k.typ); ok {
+			newData[k.legacyKey] = value
+		}
 	}
-	if v, ok := data[protocol.EntityDataKeyFlagsTwo]; ok {
-		flag2 = v.(int64)
+
+	flags, _ := newData[0].(int64)
+	flagsTwo, _ := newData[91].(int64)
+	if flags == 0 && flagsTwo == 0 {
+		return newData
 	}
-	if flag1 == 0 && flag2 == 0 {
-		return data
+	lo, hi := downgradeFlags(uint64(flags), uint64(flagsTwo))
+	newData[0] = int64(lo)
+	if _, ok := newData[91]; ok || hi != 0 {
+		newData[91] = int64(hi)
 	}
+	return newData
+}
 
-	newFlag1 := flag1 & ^(^0 << (protocol.EntityDataFlagDash - 1))
-	lastHalf := flag1 & (^0 << protocol.EntityDataFlagDash)
-	lastHalf >>= 1
-	lastHalf &= math.MaxInt64
+// UpgradeEntityMetadata upgrades entity metadata from legacy version to latest version. Block runtime IDs are
+// upgraded to those of the palette passed.
+func UpgradeEntityMetadata(data map[uint32]any, palette *latestmappings.Palette) map[uint32]any {
+	newData := make(map[uint32]any, len(data))
+	for legacyKey, value := range data {
+		key, ok := legacyMetadataKeys[legacyKey]
+		if !ok {
+			continue
+		}
+		if k := metadataSchema[key]; k.upgrade != nil {
+			if value, ok = k.upgrade(value, palette); !ok {
+				continue
+			}
+		}
+		newData[key] = value
+	}
 
-	newFlag1 |= lastHalf
+	flags, _ := newData[protocol.EntityDataKeyFlags].(int64)
+	flagsTwo, _ := newData[protocol.EntityDataKeyFlagsTwo].(int64)
+	if flags == 0 && flagsTwo == 0 {
+		return newData
+	}
+	lo, hi := upgradeFlags(uint64(flags), uint64(flagsTwo))
+	newData[protocol.EntityDataKeyFlags] = int64(lo)
+	if _, ok := newData[protocol.EntityDataKeyFlagsTwo]; ok || hi != 0 {
+		newData[protocol.EntityDataKeyFlagsTwo] = int64(hi)
+	}
+	return newData
+}
 
-	if flag2 != 0 {
-		newFlag1 ^= (flag2 & 1) << 63
-		flag2 >>= 1
-		flag2 &= math.MaxInt64
+// legacyFlagCount is the amount of entity flags that existed in v1.12.0. The last one is the flag for falling
+// through scaffolding.
+const legacyFlagCount = 71
 
-		data[protocol.EntityDataKeyFlagsTwo] = flag2
-	}
+// downgradeFlags downgrades the two entity flag fields of the latest version to v1.12.0. The dash flag did not
+// exist in v1.12.0, so every flag after it is moved down by one. Flags that did not exist in v1.12.0 are cleared.
+func downgradeFlags(lo, hi uint64) (uint64, uint64) {
+	const dash = protocol.EntityDataFlagDash
+	before := lo & (1<<dash - 1)
+	lo = lo>>(dash+1)<<dash | hi<<63 | before
+	hi = hi >> 1 & (1<<(legacyFlagCount-64) - 1)
+	return lo, hi
+}
 
-	data[protocol.EntityDataKeyFlags] = newFlag1
-	return data
+// upgradeFlags upgrades the two entity flag fields of v1.12.0 to the latest version. Every flag after the dash
+// flag, which did not exist in v1.12.0, is moved up by one.
+func upgradeFlags(lo, hi uint64) (uint64, uint64) {
+	const dash = protocol.EntityDataFlagDash
+	before := lo & (1<<dash - 1)
+	hi = hi&(1<<(legacyFlagCount-64)-1)<<1 | lo>>63
+	lo = lo>>dash<<(dash+1) | before
+	return lo, hi
 }
 
-// UpgradeEntityMetadata upgrades entity metadata from legacy version to latest version.
-func UpgradeEntityMetadata(data map[uint32]any) map[uint32]any {
-	data = upgradeKey(data)
+// convertMetadataValue converts an entity metadata value to the type passed. Numbers are converted between each
+// other, while other values must already have the type passed. False is returned if the value cannot be
+// converted.
+func convertMetadataValue(v any, typ metadataType) (any, bool) {
+	switch typ {
+	case metadataString:
+		s, ok := v.(string)
+		return s, ok
+	case metadataCompound:
+		m, ok := v.(map[string]any)
+		return m, ok
+	case metadataBlockPos:
+		pos, ok := v.(protocol.BlockPos)
+		return pos, ok
+	case metadataVec3:
+		vec, ok := v.(mgl32.Vec3)
+		return vec, ok
+	}
 
-	var flag1, flag2 int64
-	if v, ok := data[protocol.EntityDataKeyFlags]; ok {
-		flag1 = v.(int64)
+	var f float64
+	switch v := v.(type) {
+	case uint8:
+		f = float64(v)
+	case int8:
+		f = float64(v)
+	case int16:
+		f = float64(v)
+	case int32:
+		f = float64(v)
+	case int64:
+		if typ == metadataLong {
+			// Don't lose precision of large numbers, such as entity IDs and flags.
+			return v, true
+		}
+		f = float64(v)
+	case float32:
+		f = float64(v)
+	default:
+		return nil, false
 	}
-	if v, ok := data[protocol.EntityDataKeyFlagsTwo]; ok {
-		flag2 = v.(int64)
+	switch typ {
+	case metadataByte:
+		return uint8(f), true
+	case metadataShort:
+		return int16(max(math.MinInt16, min(math.MaxInt16, f))), true
+	case metadataInt:
+		return int32(max(math.MinInt32, min(math.MaxInt32, f))), true
+	case metadataFloat:
+		return float32(f), true
+	case metadataLong:
+		return int64(f), true
 	}
+	return nil, false
+}
 
-	flag2 <<= 1
-	flag2 |= (flag1 >> 63) & 1
+// downgradeDisplayBlock converts the runtime ID of the block shown in a minecart to v1.12.0, where the legacy ID
+// and metadata value of the block were stored instead.
+func downgradeDisplayBlock(v any, palette *latestmappings.Palette) (any, bool) {
+	b, ok := downgradeMetadataBlock(v, palette)
+	return int32(b.LegacyID) | int32(b.Data)<<16, ok
+}
 
-	newFlag1 := flag1 & ^(^0 << (protocol.EntityDataFlagDash - 1))
-	lastHalf := flag1 & (^0<<protocol.EntityDataFlagDash - 1)
-	lastHalf <<= 1
-	newFlag1 |= lastHalf
+// upgradeDisplayBlock converts the legacy ID and metadata value of the block shown in a minecart to the runtime
+// ID of the block in the latest version.
+func upgradeDisplayBlock(v any, palette *latestmappings.Palette) (any, bool) {
+	value, ok := v.(int32)
+	if !ok {
+		return nil, false
+	}
+	return upgradeMetadataBlock(int16(value&0xffff), int16(value>>16), palette)
+}
 
-	data[protocol.EntityDataKeyFlagsTwo] = flag2
-	data[protocol.EntityDataKeyFlags] = newFlag1
-	return data
+// downgradeCarriedBlock converts the runtime ID of the block carried by an enderman to v1.12.0, where the legacy
+// ID of the block was stored instead.
+func downgradeCarriedBlock(v any, palette *latestmappings.Palette) (any, bool) {
+	b, ok := downgradeMetadataBlock(v, palette)
+	return b.LegacyID, ok
 }
 
-// downgradeKey downgrades the latest key of an entity metadata map to the legacy key.
-func downgradeKey(data map[uint32]any) map[uint32]any {
-	newData := make(map[uint32]any)
-	for key, value := range data {
-		switch key {
-		case protocol.EntityDataKeyDataRadius:
-			key = 60
-		case protocol.EntityDataKeyDataWaiting:
-			key = 61
-		case protocol.EntityDataKeyDataParticle:
-			key = 62
-		case protocol.EntityDataKeyAttachFace:
-			key = 64
-		case protocol.EntityDataKeyAttachedPosition:
-			key = 66
-		case protocol.EntityDataKeyTradeTarget:
-			key = 67
-		case protocol.EntityDataKeyCommandName:
-			key = 70
-		case protocol.EntityDataKeyLastCommandOutput:
-			key = 71
-		case protocol.EntityDataKeyTrackCommandOutput:
-			key = 72
-		case protocol.EntityDataKeyControllingSeatIndex:
-			key = 73
-		case protocol.EntityDataKeyStrength:
-			key = 74
-		case protocol.EntityDataKeyStrengthMax:
-			key = 75
-		case protocol.EntityDataKeyDataLifetimeTicks:
-			key = 77
-		case protocol.EntityDataKeyPoseIndex:
-			key = 78
-		case protocol.EntityDataKeyDataTickOffset:
-			key = 79
-		case protocol.EntityDataKeyAlwaysShowNameTag:
-			key = 80
-		case protocol.EntityDataKeyColorTwoIndex:
-			key = 81
-		case protocol.EntityDataKeyScore:
-			key = 83
-		case protocol.EntityDataKeyBalloonAnchor:
-			key = 84
-		case protocol.EntityDataKeyPuffedState:
-			key = 85
-		case protocol.EntityDataKeyBubbleTime:
-			key = 86
-		case protocol.EntityDataKeyAgent:
-			key = 87
-		case protocol.EntityDataKeyEatingCounter:
-			key = 90
-		case protocol.EntityDataKeyFlagsTwo:
-			key = 91
-		case protocol.EntityDataKeyDataDuration:
-			key = 94
-		case protocol.EntityDataKeyDataSpawnTime:
-			key = 95
-		case protocol.EntityDataKeyDataChangeRate:
-			key = 96
-		case protocol.EntityDataKeyDataChangeOnPickup:
-			key = 97
-		case protocol.EntityDataKeyDataPickupCount:
-			key = 98
-		case protocol.EntityDataKeyInteractText:
-			key = 99
-		case protocol.EntityDataKeyTradeTier:
-			key = 100
-		case protocol.EntityDataKeyMaxTradeTier:
-			key = 101
-		case protocol.EntityDataKeyTradeExperience:
-			key = 102
-		case protocol.EntityDataKeySkinID:
-			key = 104
-		case protocol.EntityDataKeyCommandBlockTickDelay:
-			key = 105
-		case protocol.EntityDataKeyCommandBlockExecuteOnFirstTick:
-			key = 106
-		case protocol.EntityDataKeyAmbientSoundInterval:
-			key = 107
-		case protocol.EntityDataKeyAmbientSoundIntervalRange:
-			key = 108
-		case protocol.EntityDataKeyAmbientSoundEventName:
-			key = 109
-		}
-		newData[key] = value
+// upgradeCarriedBlock converts the legacy ID of the block carried by an enderman to the runtime ID of the block
+// in the latest version.
+func upgradeCarriedBlock(v any, palette *latestmappings.Palette) (any, bool) {
+	id, ok := v.(int16)
+	if !ok {
+		return nil, false
 	}
-	return newData
+	return upgradeMetadataBlock(id, 0, palette)
 }
 
-// upgradeKey upgrades the legacy key of an entity metadata map to the latest key.
-func upgradeKey(data map[uint32]any) map[uint32]any {
-	newData := make(map[uint32]any)
-	for key, value := range data {
-		switch key {
-		case 60:
-			key = protocol.EntityDataKeyDataRadius
-		case 61:
-			key = protocol.EntityDataKeyDataWaiting
-		case 62:
-			key = protocol.EntityDataKeyDataParticle
-		case 64:
-			key = protocol.EntityDataKeyAttachFace
-		case 66:
-			key = protocol.EntityDataKeyAttachedPosition
-		case 67:
-			key = protocol.EntityDataKeyTradeTarget
-		case 70:
-			key = protocol.EntityDataKeyCommandName
-		case 71:
-			key = protocol.EntityDataKeyLastCommandOutput
-		case 72:
-			key = protocol.EntityDataKeyTrackCommandOutput
-		case 73:
-			key = protocol.EntityDataKeyControllingSeatIndex
-		case 74:
-			key = protocol.EntityDataKeyStrength
-		case 75:
-			key = protocol.EntityDataKeyStrengthMax
-		case 77:
-			key = protocol.EntityDataKeyDataLifetimeTicks
-		case 78:
-			key = protocol.EntityDataKeyPoseIndex
-		case 79:
-			key = protocol.EntityDataKeyDataTickOffset
-		case 80:
-			key = protocol.EntityDataKeyAlwaysShowNameTag
-		case 81:
-			key = protocol.EntityDataKeyColorTwoIndex
-		case 83:
-			key = protocol.EntityDataKeyScore
-		case 84:
-			key = protocol.EntityDataKeyBalloonAnchor
-		case 85:
-			key = protocol.EntityDataKeyPuffedState
-		case 86:
-			key = protocol.EntityDataKeyBubbleTime
-		case 87:
-			key = protocol.EntityDataKeyAgent
-		case 90:
-			key = protocol.EntityDataKeyEatingCounter
-		case 91:
-			key = protocol.EntityDataKeyFlagsTwo
-		case 94:
-			key = protocol.EntityDataKeyDataDuration
-		case 95:
-			key = protocol.EntityDataKeyDataSpawnTime
-		case 96:
-			key = protocol.EntityDataKeyDataChangeRate
-		case 97:
-			key = protocol.EntityDataKeyDataChangeOnPickup
-		case 98:
-			key = protocol.EntityDataKeyDataPickupCount
-		case 99:
-			key = protocol.EntityDataKeyInteractText
-		case 100:
-			key = protocol.EntityDataKeyTradeTier
-		case 101:
-			key = protocol.EntityDataKeyMaxTradeTier
-		case 102:
-			key = protocol.EntityDataKeyTradeExperience
-		case 104:
-			key = protocol.EntityDataKeySkinID
-		case 105:
-			key = protocol.EntityDataKeyCommandBlockTickDelay
-		case 106:
-			key = protocol.EntityDataKeyCommandBlockExecuteOnFirstTick
-		case 107:
-			key = protocol.EntityDataKeyAmbientSoundInterval
-		case 108:
-			key = protocol.EntityDataKeyAmbientSoundIntervalRange
-		case 109:
-			key = protocol.EntityDataKeyAmbientSoundEventName
+// downgradeMetadataBlock returns the legacy block of a runtime ID of the palette passed held in entity metadata.
+func downgradeMetadataBlock(v any, palette *latestmappings.Palette) (legacymappings.BlockEntry, bool) {
+	runtimeID, ok := v.(int32)
+	if !ok {
+		return legacymappings.BlockEntry{}, false
+	}
+	name, properties, ok := palette.RuntimeIDToState(uint32(runtimeID))
+	if !ok {
+		return legacymappings.BlockEntry{}, false
+	}
+	return legacymappings.Blocks()[legacymappings.StateToRuntimeID(name, properties)], true
+}
+
+// upgradeMetadataBlock returns the runtime ID in the palette passed of the legacy block with the ID and metadata
+// value passed.
+func upgradeMetadataBlock(id, meta int16, palette *latestmappings.Palette) (any, bool) {
+	for legacyRuntimeID, b := range legacymappings.Blocks() {
+		if b.LegacyID != id || b.Data != meta {
+			continue
 		}
-		newData[key] = value
+		name, properties, _ := legacymappings.RuntimeIDToState(uint32(legacyRuntimeID))
+		runtimeID, ok := palette.StateToRuntimeID(name, properties)
+		return int32(runtimeID), ok
 	}
-	return newData
+	return nil, false
 }
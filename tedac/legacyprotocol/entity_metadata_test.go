@@ -0,0 +1,75 @@
+package legacyprotocol
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// flag returns the two entity flag fields with only the flag passed set.
+func flag(i int) (lo, hi uint64) {
+	if i < 64 {
+		return 1 << i, 0
+	}
+	return 0, 1 << (i - 64)
+}
+
+// TestDowngradeFlags checks that flags around the removed dash flag are shifted correctly in both directions.
+func TestDowngradeFlags(t *testing.T) {
+	const dash = protocol.EntityDataFlagDash
+	tests := []struct {
+		name           string
+		latest, legacy int
+	}{
+		{name: "first flag", latest: 0, legacy: 0},
+		{name: "flag before dash", latest: dash - 1, legacy: dash - 1},
+		{name: "flag after dash", latest: dash + 1, legacy: dash},
+		{name: "last flag of first field", latest: 63, legacy: 62},
+		{name: "first flag of second field", latest: 64, legacy: 63},
+		{name: "second flag of second field", latest: 65, legacy: 64},
+		{name: "last legacy flag", latest: legacyFlagCount, legacy: legacyFlagCount - 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wantLo, wantHi := flag(test.legacy)
+			if lo, hi := downgradeFlags(flag(test.latest)); lo != wantLo || hi != wantHi {
+				t.Errorf("downgradeFlags(flag %v) = (%#x, %#x), want (%#x, %#x)", test.latest, lo, hi, wantLo, wantHi)
+			}
+			latestLo, latestHi := flag(test.latest)
+			if lo, hi := upgradeFlags(wantLo, wantHi); lo != latestLo || hi != latestHi {
+				t.Errorf("upgradeFlags(flag %v) = (%#x, %#x), want (%#x, %#x)", test.legacy, lo, hi, latestLo, latestHi)
+			}
+		})
+	}
+}
+
+// TestDowngradeFlagsCleared checks that flags without a v1.12.0 equivalent are cleared when downgrading.
+func TestDowngradeFlagsCleared(t *testing.T) {
+	for _, i := range []int{protocol.EntityDataFlagDash, legacyFlagCount + 1, 127} {
+		if lo, hi := downgradeFlags(flag(i)); lo != 0 || hi != 0 {
+			t.Errorf("downgradeFlags(flag %v) = (%#x, %#x), want (0, 0)", i, lo, hi)
+		}
+	}
+}
+
+// TestFlagsRoundTrip checks that every flag shared by both versions survives downgrading and upgrading.
+func TestFlagsRoundTrip(t *testing.T) {
+	// Set every flag of the latest version that also existed in v1.12.0.
+	var lo, hi uint64
+	for i := 0; i <= legacyFlagCount; i++ {
+		if i == protocol.EntityDataFlagDash {
+			continue
+		}
+		l, h := flag(i)
+		lo, hi = lo|l, hi|h
+	}
+	if gotLo, gotHi := upgradeFlags(downgradeFlags(lo, hi)); gotLo != lo || gotHi != hi {
+		t.Errorf("upgradeFlags(downgradeFlags(%#x, %#x)) = (%#x, %#x)", lo, hi, gotLo, gotHi)
+	}
+
+	// Every flag of v1.12.0 must survive the opposite direction as well.
+	legacyLo, legacyHi := uint64(1<<64-1), uint64(1<<(legacyFlagCount-64)-1)
+	if gotLo, gotHi := downgradeFlags(upgradeFlags(legacyLo, legacyHi)); gotLo != legacyLo || gotHi != legacyHi {
+		t.Errorf("downgradeFlags(upgradeFlags(%#x, %#x)) = (%#x, %#x)", legacyLo, legacyHi, gotLo, gotHi)
+	}
+}
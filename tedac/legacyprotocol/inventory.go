@@ -16,9 +16,26 @@ const (
 	WindowIDInventory = 0
 	WindowIDOffHand   = 119
 	WindowIDArmour    = 120
+	WindowIDCreative  = 121
 	WindowIDUI        = 124
 )
 
+// Fake window IDs used for the InventoryActionSourceTODO source type. These are used for windows that only
+// exist client-side, such as the crafting grid.
+const (
+	WindowIDCraftingAddIngredient    = -2
+	WindowIDCraftingRemoveIngredient = -3
+	WindowIDCraftingResult           = -4
+	WindowIDCraftingUseIngredient    = -5
+)
+
+// Slots of actions with the InventoryActionSourceCreative source type. The slot indicates if the item of the action
+// was taken from the creative inventory or put back in it to be deleted.
+const (
+	CreativeSlotDelete = 0
+	CreativeSlotCreate = 1
+)
+
 // InventoryAction represents a single action that took place during an inventory transaction. On itself, this
 // inventory action is always unbalanced: It must be combined with other actions in an inventory transaction
 // to form a balanced transaction.
@@ -106,7 +106,7 @@ func WriteItem(w *protocol.Writer, x *ItemStack) {
 		// The item was air, so there's no more data to follow. Return immediately.
 		return
 	}
-	aux := int32(x.MetadataValue<<8) | int32(x.Count)
+	aux := int32(x.MetadataValue)<<8 | int32(x.Count)
 	w.Varint32(&aux)
 	if len(x.NBTData) != 0 {
 		userDataMarker := int16(-1)
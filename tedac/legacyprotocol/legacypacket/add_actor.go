@@ -36,7 +36,7 @@ type AddActor struct {
 	HeadYaw float32
 	// Attributes is a slice of attributes that the entity has. It includes attributes such as its health,
 	// movement speed, etc.
-	Attributes []legacyprotocol.Attribute
+	Attributes []legacyprotocol.AttributeValue
 	// EntityMetadata is a map of entity metadata, which includes flags and data properties that alter in
 	// particular the way the entity looks. Flags include ones such as 'on fire' and 'sprinting'.
 	// The metadata values are indexed by their property key.
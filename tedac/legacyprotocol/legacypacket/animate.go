@@ -0,0 +1,35 @@
+package legacypacket
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Animate is sent by the server to send a player animation from one player to all viewers of that player. It
+// is also sent by the client when it swings its arm or paddles the boat it is sitting in.
+type Animate struct {
+	// ActionType is the ID of the animation action to execute. It is one of the action type constants that
+	// may be found in the packet package.
+	ActionType int32
+	// EntityRuntimeID is the runtime ID of the player that the animation should be played upon. The runtime
+	// ID is unique for each world session, and entities are generally identified in packets using this
+	// runtime ID.
+	EntityRuntimeID uint64
+	// BoatRowingTime is the time that the player has been rowing the boat for. It is only present if the
+	// action type is one of the rowing actions.
+	BoatRowingTime float32
+}
+
+// ID ...
+func (*Animate) ID() uint32 {
+	return packet.IDAnimate
+}
+
+// Marshal ...
+func (pk *Animate) Marshal(io protocol.IO) {
+	io.Varint32(&pk.ActionType)
+	io.Varuint64(&pk.EntityRuntimeID)
+	if pk.ActionType&0x80 != 0 {
+		io.Float32(&pk.BoatRowingTime)
+	}
+}
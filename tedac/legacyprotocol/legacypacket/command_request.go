@@ -3,6 +3,7 @@ package legacypacket
 import (
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
 )
 
 // CommandRequest is sent by the client to request the execution of a server-side command. Although some
@@ -14,7 +15,7 @@ type CommandRequest struct {
 	CommandLine string
 	// CommandOrigin is the data specifying the origin of the command. In other words, the source that the
 	// command was from, such as the player itself or a websocket server.
-	CommandOrigin protocol.CommandOrigin
+	CommandOrigin legacyprotocol.CommandOrigin
 	// Internal specifies if the command request internal. Setting it to false seems to work and the usage of
 	// this field is not known.
 	Internal bool
@@ -28,6 +29,6 @@ func (*CommandRequest) ID() uint32 {
 // Marshal ...
 func (pk *CommandRequest) Marshal(io protocol.IO) {
 	io.String(&pk.CommandLine)
-	protocol.CommandOriginData(io, &pk.CommandOrigin)
+	legacyprotocol.CommandOriginData(io, &pk.CommandOrigin)
 	io.Bool(&pk.Internal)
 }
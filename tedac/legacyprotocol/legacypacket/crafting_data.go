@@ -0,0 +1,88 @@
+package legacypacket
+
+import (
+	"fmt"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+)
+
+// CraftingData is sent by the server to let the client know all crafting data that the server maintains.
+// This includes shapeless crafting, crafting table recipes and furnace recipes. Each crafting station's
+// recipes are included in it.
+type CraftingData struct {
+	// Recipes is a list of all recipes available on the server. It includes among others shapeless, shaped
+	// and furnace recipes. The client will only be able to craft these recipes.
+	Recipes []legacyprotocol.Recipe
+	// ClearRecipes indicates if all recipes currently active on the client should be cleaned. Doing this
+	// means that the client will have no recipes active by itself: Any CraftingData packets previously sent
+	// will also be discarded, and only the recipes in this CraftingData packet will be used.
+	ClearRecipes bool
+}
+
+// ID ...
+func (*CraftingData) ID() uint32 {
+	return packet.IDCraftingData
+}
+
+// Marshal ...
+func (pk *CraftingData) Marshal(io protocol.IO) {
+	legacyprotocol.IoBackwardsCompatibility(io, pk.unmarshal, pk.marshal)
+	io.Bool(&pk.ClearRecipes)
+}
+
+// marshal ...
+func (pk *CraftingData) marshal(w *protocol.Writer) {
+	l := uint32(len(pk.Recipes))
+	w.Varuint32(&l)
+	for _, recipe := range pk.Recipes {
+		var c int32
+		switch recipe.(type) {
+		case *legacyprotocol.ShapelessRecipe:
+			c = legacyprotocol.RecipeShapeless
+		case *legacyprotocol.ShapedRecipe:
+			c = legacyprotocol.RecipeShaped
+		case *legacyprotocol.FurnaceRecipe:
+			c = legacyprotocol.RecipeFurnace
+		case *legacyprotocol.FurnaceDataRecipe:
+			c = legacyprotocol.RecipeFurnaceData
+		case *legacyprotocol.MultiRecipe:
+			c = legacyprotocol.RecipeMulti
+		default:
+			w.UnknownEnumOption(fmt.Sprintf("%T", recipe), "crafting recipe type")
+		}
+		w.Varint32(&c)
+		recipe.Marshal(w)
+	}
+}
+
+// unmarshal ...
+func (pk *CraftingData) unmarshal(r *protocol.Reader) {
+	var length uint32
+	r.Varuint32(&length)
+	pk.Recipes = make([]legacyprotocol.Recipe, length)
+	for i := uint32(0); i < length; i++ {
+		var recipeType int32
+		r.Varint32(&recipeType)
+
+		var recipe legacyprotocol.Recipe
+		switch recipeType {
+		case legacyprotocol.RecipeShapeless:
+			recipe = &legacyprotocol.ShapelessRecipe{}
+		case legacyprotocol.RecipeShaped:
+			recipe = &legacyprotocol.ShapedRecipe{}
+		case legacyprotocol.RecipeFurnace:
+			recipe = &legacyprotocol.FurnaceRecipe{}
+		case legacyprotocol.RecipeFurnaceData:
+			recipe = &legacyprotocol.FurnaceDataRecipe{}
+		case legacyprotocol.RecipeMulti:
+			recipe = &legacyprotocol.MultiRecipe{}
+		default:
+			r.UnknownEnumOption(recipeType, "crafting data recipe type")
+			return
+		}
+		recipe.Unmarshal(r)
+		pk.Recipes[i] = recipe
+	}
+}
@@ -0,0 +1,34 @@
+package legacypacket
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// PlayerInput is sent by the client to the server when the player is moving but the server does not allow it
+// to update its movement using the MovePlayer packet. It includes situations where the player is riding an
+// entity like a boat. If this is the case, the packet is sent roughly every tick.
+type PlayerInput struct {
+	// Movement is the movement vector of the input. It should be thought of in Pocket Edition, where the
+	// movement vector is the direction of the joystick. It holds values ranging from -1 to 1.
+	Movement mgl32.Vec2
+	// Jumping indicates if the player was pressing the jump button or not. It is used to make horses jump.
+	Jumping bool
+	// Sneaking indicates if the player was sneaking during the input. Note that this may not be checked to
+	// send the sneaking flag to the server, as the client will send a PlayerAction for that.
+	Sneaking bool
+}
+
+const IDPlayerInput = 57
+
+// ID ...
+func (*PlayerInput) ID() uint32 {
+	return IDPlayerInput
+}
+
+// Marshal ...
+func (pk *PlayerInput) Marshal(io protocol.IO) {
+	io.Vec2(&pk.Movement)
+	io.Bool(&pk.Jumping)
+	io.Bool(&pk.Sneaking)
+}
@@ -0,0 +1,176 @@
+package legacyprotocol
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+const (
+	RecipeShapeless = iota
+	RecipeShaped
+	RecipeFurnace
+	RecipeFurnaceData
+	RecipeMulti
+)
+
+// Recipe represents a recipe that may be sent in a CraftingData packet to let the client know what recipes
+// are available server-side.
+type Recipe interface {
+	// Marshal encodes the recipe data to its binary representation into w.
+	Marshal(w *protocol.Writer)
+	// Unmarshal decodes a serialised recipe from Reader r into the recipe instance.
+	Unmarshal(r *protocol.Reader)
+}
+
+// ShapelessRecipe is a recipe that has no particular shape. Its functionality is shared with the
+// RecipeShulkerBox and RecipeShapelessChemistry types of newer versions.
+type ShapelessRecipe struct {
+	// Input is a list of items that serve as the input of the shapeless recipe. These items are the items
+	// required to craft the output. Unlike newer versions, every input is a full item stack.
+	Input []ItemStack
+	// Output is a list of items that are created as a result of crafting the recipe.
+	Output []ItemStack
+	// UUID is a UUID identifying the recipe. This can actually be set to an empty UUID if the CraftingEvent
+	// packet is not used.
+	UUID uuid.UUID
+}
+
+// ShapedRecipe is a recipe that has a specific shape that must be used to craft the output of the recipe.
+type ShapedRecipe struct {
+	// Width is the width of the recipe's shape.
+	Width int32
+	// Height is the height of the recipe's shape.
+	Height int32
+	// Input is a list of items that serve as the input of the shaped recipe. These items are the items
+	// required to craft the output. The amount of input items must be exactly equal to Width * Height.
+	Input []ItemStack
+	// Output is a list of items that are created as a result of crafting the recipe.
+	Output []ItemStack
+	// UUID is a UUID identifying the recipe. This can actually be set to an empty UUID if the CraftingEvent
+	// packet is not used.
+	UUID uuid.UUID
+}
+
+// FurnaceRecipe is a recipe that is specifically used for all kinds of furnaces. These recipes don't just
+// apply to furnaces, but also blast furnaces and smokers.
+type FurnaceRecipe struct {
+	// InputType is the item type of the input item. The metadata value of the item is not used in the
+	// FurnaceRecipe. Use FurnaceDataRecipe to allow an item with only one metadata value.
+	InputType ItemType
+	// Output is the item that is created as a result of smelting/cooking an item in the furnace.
+	Output ItemStack
+}
+
+// FurnaceDataRecipe is a recipe specifically used for furnace-type crafting stations. It is equal to
+// FurnaceRecipe, except it has an input item with a specific metadata value, instead of any metadata value.
+type FurnaceDataRecipe struct {
+	FurnaceRecipe
+}
+
+// MultiRecipe serves as an 'enable' switch for multi-shape recipes.
+type MultiRecipe struct {
+	// UUID is a UUID identifying the recipe. This can actually be set to an empty UUID if the CraftingEvent
+	// packet is not used.
+	UUID uuid.UUID
+}
+
+// Marshal ...
+func (recipe *ShapelessRecipe) Marshal(w *protocol.Writer) {
+	writeItems(w, recipe.Input)
+	writeItems(w, recipe.Output)
+	w.UUID(&recipe.UUID)
+}
+
+// Unmarshal ...
+func (recipe *ShapelessRecipe) Unmarshal(r *protocol.Reader) {
+	recipe.Input = readItems(r)
+	recipe.Output = readItems(r)
+	r.UUID(&recipe.UUID)
+}
+
+// Marshal ...
+func (recipe *ShapedRecipe) Marshal(w *protocol.Writer) {
+	w.Varint32(&recipe.Width)
+	w.Varint32(&recipe.Height)
+	for i := range recipe.Input {
+		WriteItem(w, &recipe.Input[i])
+	}
+	writeItems(w, recipe.Output)
+	w.UUID(&recipe.UUID)
+}
+
+// Unmarshal ...
+func (recipe *ShapedRecipe) Unmarshal(r *protocol.Reader) {
+	r.Varint32(&recipe.Width)
+	r.Varint32(&recipe.Height)
+	LimitInt32(recipe.Width, 0, lowerLimit)
+	LimitInt32(recipe.Height, 0, lowerLimit)
+
+	recipe.Input = make([]ItemStack, recipe.Width*recipe.Height)
+	for i := range recipe.Input {
+		ReadItem(r, &recipe.Input[i])
+	}
+	recipe.Output = readItems(r)
+	r.UUID(&recipe.UUID)
+}
+
+// Marshal ...
+func (recipe *FurnaceRecipe) Marshal(w *protocol.Writer) {
+	w.Varint32(&recipe.InputType.NetworkID)
+	WriteItem(w, &recipe.Output)
+}
+
+// Unmarshal ...
+func (recipe *FurnaceRecipe) Unmarshal(r *protocol.Reader) {
+	r.Varint32(&recipe.InputType.NetworkID)
+	ReadItem(r, &recipe.Output)
+}
+
+// Marshal ...
+func (recipe *FurnaceDataRecipe) Marshal(w *protocol.Writer) {
+	w.Varint32(&recipe.InputType.NetworkID)
+	meta := int32(recipe.InputType.MetadataValue)
+	w.Varint32(&meta)
+	WriteItem(w, &recipe.Output)
+}
+
+// Unmarshal ...
+func (recipe *FurnaceDataRecipe) Unmarshal(r *protocol.Reader) {
+	r.Varint32(&recipe.InputType.NetworkID)
+	var meta int32
+	r.Varint32(&meta)
+	recipe.InputType.MetadataValue = int16(meta)
+	ReadItem(r, &recipe.Output)
+}
+
+// Marshal ...
+func (recipe *MultiRecipe) Marshal(w *protocol.Writer) {
+	w.UUID(&recipe.UUID)
+}
+
+// Unmarshal ...
+func (recipe *MultiRecipe) Unmarshal(r *protocol.Reader) {
+	r.UUID(&recipe.UUID)
+}
+
+// writeItems writes a list of item stacks x, prefixed by its length, to Writer w.
+func writeItems(w *protocol.Writer, x []ItemStack) {
+	l := uint32(len(x))
+	w.Varuint32(&l)
+	for i := range x {
+		WriteItem(w, &x[i])
+	}
+}
+
+// readItems reads a list of item stacks, prefixed by its length, from Reader r.
+func readItems(r *protocol.Reader) []ItemStack {
+	var l uint32
+	r.Varuint32(&l)
+	LimitUint32(l, higherLimit)
+
+	x := make([]ItemStack, l)
+	for i := range x {
+		ReadItem(r, &x[i])
+	}
+	return x
+}
@@ -0,0 +1,80 @@
+package tedac
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol/legacypacket"
+)
+
+// addParticleMask is set in the type of level events that add a particle. The other bits of the type hold the ID
+// of the particle.
+const addParticleMask = 0x4000
+
+// downgradeLevelEvent downgrades a level event of the latest version to v1.12.0, including the particles added by
+// it. Level events and particles added after v1.12.0 are replaced with a similar one, and false is returned if
+// there is none. Block and item data of the level event is downgraded as well.
+func (s *session) downgradeLevelEvent(pk *packet.LevelEvent) (*packet.LevelEvent, bool) {
+	if pk.EventType&addParticleMask != 0 {
+		return s.downgradeParticle(pk)
+	}
+	eventData := pk.EventData
+	// The level events are compared by their ID in the latest version, so the data is converted before the type.
+	switch pk.EventType {
+	case packet.LevelEventParticlesDestroyBlock, packet.LevelEventParticlesDestroyBlockNoSound:
+		eventData = int32(s.downgradeBlock(uint32(eventData)))
+	case packet.LevelEventParticlesCrackBlock:
+		// The face of the block cracked is stored in the upper bits of the data.
+		face := eventData &^ 0xffffff
+		eventData = int32(s.downgradeBlock(uint32(eventData&0xffffff))) | face
+	}
+	eventType, ok := legacymappings.DowngradeLevelEvent(pk.EventType)
+	if !ok {
+		return nil, false
+	}
+	return &packet.LevelEvent{
+		EventType: eventType,
+		Position:  s.downgradePos(pk.Position),
+		EventData: eventData,
+	}, true
+}
+
+// downgradeParticle downgrades a level event that adds a particle to v1.12.0.
+func (s *session) downgradeParticle(pk *packet.LevelEvent) (*packet.LevelEvent, bool) {
+	particle, ok := legacymappings.DowngradeParticle(pk.EventType &^ addParticleMask)
+	if !ok {
+		return nil, false
+	}
+	eventData := pk.EventData
+	switch legacymappings.ParticleName(particle) {
+	case "icon_crack":
+		// The network ID of the item is stored in the upper bits of the data, and the metadata value in the lower bits.
+		item := s.downgradeItem(protocol.ItemStack{ItemType: protocol.ItemType{
+			NetworkID:     eventData >> 16,
+			MetadataValue: uint32(eventData & 0xffff),
+		}})
+		eventData = item.NetworkID<<16 | int32(uint16(item.MetadataValue))
+	case "terrain":
+		eventData = int32(s.downgradeBlock(uint32(eventData)))
+	}
+	return &packet.LevelEvent{
+		EventType: particle | addParticleMask,
+		Position:  s.downgradePos(pk.Position),
+		EventData: eventData,
+	}, true
+}
+
+// downgradeParticleEffect downgrades a SpawnParticleEffect packet to v1.12.0. Vanilla particle effects added after
+// v1.12.0 are replaced with a similar one, and false is returned if there is none.
+func (s *session) downgradeParticleEffect(pk *packet.SpawnParticleEffect) (*legacypacket.SpawnParticleEffect, bool) {
+	name, ok := legacymappings.DowngradeParticleEffect(pk.ParticleName)
+	if !ok {
+		return nil, false
+	}
+	return &legacypacket.SpawnParticleEffect{
+		Dimension:      pk.Dimension,
+		EntityUniqueID: pk.EntityUniqueID,
+		Position:       s.downgradePos(pk.Position),
+		ParticleName:   name,
+	}, true
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/samber/lo"
 	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"github.com/tedacmc/tedac/tedac/chunk"
@@ -52,6 +54,9 @@ func (Protocol) Packets(bool) packet.Pool {
 	pool[packet.IDSetTitle] = func() packet.Packet { return &legacypacket.SetTitle{} }
 	pool[legacypacket.IDEntityFall] = func() packet.Packet { return &legacypacket.EntityFall{} }
 	pool[legacypacket.IDTickSync] = func() packet.Packet { return &legacypacket.TickSync{} }
+	pool[legacypacket.IDPlayerInput] = func() packet.Packet { return &legacypacket.PlayerInput{} }
+	pool[packet.IDAnimate] = func() packet.Packet { return &legacypacket.Animate{} }
+	pool[packet.IDLevelSoundEvent] = func() packet.Packet { return &legacypacket.LevelSoundEvent{} }
 	return pool
 }
 
@@ -74,7 +79,8 @@ func (Protocol) Encryption(key [32]byte) packet.Encryption {
 var nullBytes = []byte("null\n")
 
 // ConvertToLatest ...
-func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Packet {
+func (Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
+	s := sessionFor(conn)
 	switch pk := pk.(type) {
 	case *legacypacket.SetTitle:
 		return []packet.Packet{
@@ -119,10 +125,13 @@ func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Pa
 			},
 		}
 	case *legacypacket.MovePlayer:
+		// Movement while riding also updates the vehicle rotation, which is sent in PlayerAuthInput along with
+		// the position of the player.
+		s.moveVehicle(pk)
 		return []packet.Packet{
 			&packet.MovePlayer{
 				EntityRuntimeID:       pk.EntityRuntimeID,
-				Position:              pk.Position,
+				Position:              s.upgradePos(pk.Position),
 				Pitch:                 pk.Pitch,
 				Yaw:                   pk.Yaw,
 				HeadYaw:               pk.HeadYaw,
@@ -137,11 +146,20 @@ func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Pa
 			&packet.PlayerAction{
 				EntityRuntimeID: pk.EntityRuntimeID,
 				ActionType:      pk.ActionType,
-				BlockPosition:   pk.BlockPosition,
+				BlockPosition:   s.upgradeBlockPos(pk.BlockPosition),
 				BlockFace:       pk.BlockFace,
 			},
 		}
 	case *legacypacket.InventoryTransaction:
+		if _, ok := pk.TransactionData.(*legacyprotocol.NormalTransactionData); ok {
+			if request, ok := s.craftingRequest(pk.Actions); ok {
+				return []packet.Packet{request}
+			}
+			if request, ok := s.creativeRequest(pk.Actions); ok {
+				return []packet.Packet{request}
+			}
+		}
+
 		actions := make([]protocol.InventoryAction, 0, len(pk.Actions))
 		for _, action := range pk.Actions {
 			actions = append(actions, protocol.InventoryAction{
@@ -149,8 +167,8 @@ func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Pa
 				WindowID:      action.WindowID,
 				SourceFlags:   action.SourceFlags,
 				InventorySlot: action.InventorySlot,
-				OldItem:       protocol.ItemInstance{Stack: upgradeItem(action.OldItem)},
-				NewItem:       protocol.ItemInstance{Stack: upgradeItem(action.NewItem)},
+				OldItem:       protocol.ItemInstance{Stack: s.upgradeItem(action.OldItem)},
+				NewItem:       protocol.ItemInstance{Stack: s.upgradeItem(action.NewItem)},
 			})
 		}
 
@@ -163,29 +181,29 @@ func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Pa
 		case *legacyprotocol.UseItemTransactionData:
 			transactionData = &protocol.UseItemTransactionData{
 				ActionType:      data.ActionType,
-				BlockPosition:   data.BlockPosition,
+				BlockPosition:   s.upgradeBlockPos(data.BlockPosition),
 				BlockFace:       data.BlockFace,
 				HotBarSlot:      data.HotBarSlot,
-				HeldItem:        protocol.ItemInstance{Stack: upgradeItem(data.HeldItem)},
-				Position:        data.Position,
+				HeldItem:        protocol.ItemInstance{Stack: s.upgradeItem(data.HeldItem)},
+				Position:        s.upgradePos(data.Position),
 				ClickedPosition: data.ClickedPosition,
-				BlockRuntimeID:  upgradeBlockRuntimeID(data.BlockRuntimeID),
+				BlockRuntimeID:  s.upgradeBlock(data.BlockRuntimeID),
 			}
 		case *legacyprotocol.UseItemOnEntityTransactionData:
 			transactionData = &protocol.UseItemOnEntityTransactionData{
 				TargetEntityRuntimeID: data.TargetEntityRuntimeID,
 				ActionType:            data.ActionType,
 				HotBarSlot:            data.HotBarSlot,
-				HeldItem:              protocol.ItemInstance{Stack: upgradeItem(data.HeldItem)},
-				Position:              data.Position,
+				HeldItem:              protocol.ItemInstance{Stack: s.upgradeItem(data.HeldItem)},
+				Position:              s.upgradePos(data.Position),
 				ClickedPosition:       data.ClickedPosition,
 			}
 		case *legacyprotocol.ReleaseItemTransactionData:
 			transactionData = &protocol.ReleaseItemTransactionData{
 				ActionType:   data.ActionType,
 				HotBarSlot:   data.HotBarSlot,
-				HeldItem:     protocol.ItemInstance{Stack: upgradeItem(data.HeldItem)},
-				HeadPosition: data.HeadPosition,
+				HeldItem:     protocol.ItemInstance{Stack: s.upgradeItem(data.HeldItem)},
+				HeadPosition: s.upgradePos(data.HeadPosition),
 			}
 		}
 
@@ -200,7 +218,7 @@ func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Pa
 		var cancelReason protocol.Optional[uint8]
 		if !bytes.Equal(pk.ResponseData, nullBytes) {
 			// The response data is not null, so it is a valid response.
-			response = protocol.Option(pk.ResponseData)
+			response = protocol.Option(s.upgradeFormResponse(pk.FormID, pk.ResponseData))
 		} else {
 			// We can always default to the user closed reason if the response data doesn't exist.
 			cancelReason = protocol.Option[uint8](packet.ModalFormCancelReasonUserClosed)
@@ -216,13 +234,14 @@ func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Pa
 		return []packet.Packet{
 			&packet.MobEquipment{
 				EntityRuntimeID: pk.EntityRuntimeID,
-				NewItem:         protocol.ItemInstance{Stack: upgradeItem(pk.NewItem)},
+				NewItem:         protocol.ItemInstance{Stack: s.upgradeItem(pk.NewItem)},
 				InventorySlot:   pk.InventorySlot,
 				HotBarSlot:      pk.HotBarSlot,
 				WindowID:        pk.WindowID,
 			},
 		}
 	case *legacypacket.ContainerClose:
+		s.closeContainer()
 		return []packet.Packet{
 			&packet.ContainerClose{
 				WindowID:   pk.WindowID,
@@ -233,10 +252,20 @@ func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Pa
 		return []packet.Packet{
 			&packet.CommandRequest{
 				CommandLine:   pk.CommandLine,
-				CommandOrigin: pk.CommandOrigin,
+				CommandOrigin: s.upgradeCommandOrigin(pk.CommandOrigin),
 				Internal:      pk.Internal,
 			},
 		}
+	case *packet.BlockActorData:
+		pk.Position = s.upgradeBlockPos(pk.Position)
+		pk.NBTData = shiftBlockEntity(upgradeBlockEntity(pk.NBTData), -s.yOffset)
+	case *packet.BlockPickRequest:
+		pk.Position = s.upgradeBlockPos(pk.Position)
+	case *legacypacket.LevelSoundEvent:
+		if sound, ok := s.upgradeSound(pk); ok {
+			return []packet.Packet{sound}
+		}
+		return nil
 	case *packet.AdventureSettings:
 		// TODO: Send request ability instead?
 		return nil
@@ -244,18 +273,35 @@ func (Protocol) ConvertToLatest(pk packet.Packet, _ *minecraft.Conn) []packet.Pa
 		return nil
 	case *legacypacket.TickSync:
 		return nil
+	case *legacypacket.PlayerInput:
+		// The latest version sends vehicle input in PlayerAuthInput, which is filled using VehicleInput.
+		s.vehicleInput(pk)
+		return nil
+	case *legacypacket.Animate:
+		if s.paddle(pk.ActionType) {
+			// Paddling is sent as input flags of PlayerAuthInput in the latest version.
+			return nil
+		}
+		return []packet.Packet{
+			&packet.Animate{
+				ActionType:      pk.ActionType,
+				EntityRuntimeID: pk.EntityRuntimeID,
+			},
+		}
 	}
 	return []packet.Packet{pk}
 }
 
 // ConvertFromLatest ...
 func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
+	s := sessionFor(conn)
 	switch pk := pk.(type) {
 	case *packet.RequestNetworkSettings:
 		return []packet.Packet{
 			&packet.RequestNetworkSettings{ClientProtocol: protocol.CurrentProtocol},
 		}
 	case *packet.BiomeDefinitionList:
+		s.registerCustomBiomes(pk)
 		return []packet.Packet{
 			&legacypacket.BiomeDefinitionList{SerialisedBiomeDefinitions: legacySerialisedBiomeDefinitions},
 		}
@@ -283,6 +329,10 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 				StopAll:   pk.StopAll,
 			},
 		}
+	case *packet.ModalFormRequest:
+		pk.FormData = s.downgradeForm(pk.FormID, pk.FormData)
+	case *packet.ServerSettingsResponse:
+		pk.FormData = s.downgradeForm(pk.FormID, pk.FormData)
 	case *packet.Text:
 		return []packet.Packet{
 			&legacypacket.Text{
@@ -309,13 +359,13 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			},
 		}
 	case *packet.StartGame:
-		// TODO: Adjust our mappings to account for any possible custom blocks.
+		s.adjustCustomBlocks(pk.Blocks)
 		return []packet.Packet{
 			&legacypacket.StartGame{
 				EntityUniqueID:                 pk.EntityUniqueID,
 				EntityRuntimeID:                pk.EntityRuntimeID,
 				PlayerGameMode:                 pk.PlayerGameMode,
-				PlayerPosition:                 pk.PlayerPosition,
+				PlayerPosition:                 s.downgradePos(pk.PlayerPosition),
 				Pitch:                          pk.Pitch,
 				Yaw:                            pk.Yaw,
 				WorldSeed:                      int32(pk.WorldSeed),
@@ -323,7 +373,7 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 				Generator:                      pk.Generator,
 				WorldGameMode:                  pk.WorldGameMode,
 				Difficulty:                     pk.Difficulty,
-				WorldSpawn:                     pk.WorldSpawn,
+				WorldSpawn:                     s.downgradeBlockPos(pk.WorldSpawn),
 				AchievementsDisabled:           pk.AchievementsDisabled,
 				DayCycleLockTime:               pk.DayCycleLockTime,
 				EducationFeaturesEnabled:       pk.EducationFeaturesEnabled,
@@ -365,24 +415,37 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 		// TODO: Support other sub-chunk request modes.
 		buf := bytes.NewBuffer(pk.RawPayload)
 		oldFormat := conn.GameData().BaseGameVersion == "1.17.40"
-		c, err := chunk.NetworkDecode(latestAirRID, buf, int(pk.SubChunkCount), oldFormat, world.Overworld.Range())
+		palette := s.latestPalette()
+		c, err := chunk.NetworkDecode(palette.AirRuntimeID(), buf, int(pk.SubChunkCount), oldFormat, world.Overworld.Range())
 		if err != nil {
 			fmt.Println(err)
 			return nil
 		}
 
-		writeBuf, data := bytes.NewBuffer(nil), legacychunk.Encode(downgradeChunk(c), legacychunk.NetworkEncoding)
+		downgraded := s.downgradeChunk(c, palette)
+		writeBuf, data := bytes.NewBuffer(nil), legacychunk.Encode(downgraded, legacychunk.NetworkEncoding)
 		for i := range data.SubChunks {
 			_, _ = writeBuf.Write(data.SubChunks[i])
 		}
 		_, _ = writeBuf.Write(data.Data2D)
 
+		if border, err := buf.ReadByte(); err == nil {
+			_ = writeBuf.WriteByte(border)
+			_, _ = writeBuf.Write(buf.Next(int(border)))
+		}
+		blockEntities, legacyBlockEntities := downgradeBlockEntities(writeBuf, buf, s.yOffset)
+		if d := s.download.Load(); d != nil {
+			if err := d.storeChunk(pk.Position, c, palette, downgraded, blockEntities, legacyBlockEntities); err != nil {
+				fmt.Println(err)
+			}
+		}
+
 		return []packet.Packet{
 			&legacypacket.LevelChunk{
 				BlobHashes:    pk.BlobHashes,
 				CacheEnabled:  pk.CacheEnabled,
 				Position:      pk.Position,
-				RawPayload:    append(writeBuf.Bytes(), buf.Bytes()...),
+				RawPayload:    writeBuf.Bytes(),
 				SubChunkCount: uint32(len(data.SubChunks)),
 			},
 		}
@@ -395,13 +458,44 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			},
 		}
 	case *packet.UpdateBlock:
-		pk.NewBlockRuntimeID = downgradeBlockRuntimeID(pk.NewBlockRuntimeID)
+		pk.Position = s.downgradeBlockPos(pk.Position)
+		pk.NewBlockRuntimeID = s.downgradeBlock(pk.NewBlockRuntimeID)
 	case *packet.UpdateBlockSynced:
-		pk.NewBlockRuntimeID = downgradeBlockRuntimeID(pk.NewBlockRuntimeID)
+		pk.Position = s.downgradeBlockPos(pk.Position)
+		pk.NewBlockRuntimeID = s.downgradeBlock(pk.NewBlockRuntimeID)
+	case *packet.BlockActorData:
+		blockEntity, ok := DowngradeBlockEntity(pk.NBTData)
+		if !ok {
+			return nil
+		}
+		pk.Position = s.downgradeBlockPos(pk.Position)
+		pk.NBTData = shiftBlockEntity(blockEntity, s.yOffset)
+	case *packet.BlockEvent:
+		pk.Position = s.downgradeBlockPos(pk.Position)
+	case *packet.SetSpawnPosition:
+		pk.Position = s.downgradeBlockPos(pk.Position)
+		pk.SpawnPosition = s.downgradeBlockPos(pk.SpawnPosition)
+	case *packet.MoveActorAbsolute:
+		pk.Position = s.downgradePos(pk.Position)
+	case *packet.MoveActorDelta:
+		pk.Position = s.downgradePos(pk.Position)
+	case *packet.ChangeDimension:
+		pk.Position = s.downgradePos(pk.Position)
+	case *packet.Respawn:
+		pk.Position = s.downgradePos(pk.Position)
+	case *packet.AddPainting:
+		pk.Position = s.downgradePos(pk.Position)
+	case *packet.SpawnParticleEffect:
+		if effect, ok := s.downgradeParticleEffect(pk); ok {
+			return []packet.Packet{effect}
+		}
+		return nil
+	case *packet.PlaySound:
+		pk.Position = s.downgradePos(pk.Position)
 	case *packet.NetworkChunkPublisherUpdate:
 		return []packet.Packet{
 			&legacypacket.NetworkChunkPublisherUpdate{
-				Position: pk.Position,
+				Position: s.downgradeBlockPos(pk.Position),
 				Radius:   pk.Radius,
 			},
 		}
@@ -409,7 +503,7 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 		return []packet.Packet{
 			&legacypacket.MovePlayer{
 				EntityRuntimeID:       pk.EntityRuntimeID,
-				Position:              pk.Position,
+				Position:              s.downgradePos(pk.Position),
 				Pitch:                 pk.Pitch,
 				Yaw:                   pk.Yaw,
 				HeadYaw:               pk.HeadYaw,
@@ -427,36 +521,25 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			},
 		}
 	case *packet.AddActor:
+		entityType, metadata := s.addEntity(pk.EntityRuntimeID, pk.EntityUniqueID, pk.EntityType, pk.EntityMetadata)
+		s.linkVehicles(pk.EntityLinks, conn.GameData().EntityUniqueID)
 		return []packet.Packet{
 			&legacypacket.AddActor{
-				EntityMetadata:  legacyprotocol.DowngradeEntityMetadata(pk.EntityMetadata),
+				EntityMetadata:  legacyprotocol.DowngradeEntityMetadata(metadata, s.latestPalette()),
 				EntityRuntimeID: pk.EntityRuntimeID,
-				EntityType:      pk.EntityType,
+				EntityType:      entityType,
 				EntityUniqueID:  pk.EntityUniqueID,
 				HeadYaw:         pk.HeadYaw,
 				Pitch:           pk.Pitch,
-				Position:        pk.Position,
+				Position:        s.downgradePos(pk.Position),
 				Velocity:        pk.Velocity,
 				Yaw:             pk.Yaw,
-				Attributes: lo.Map(pk.Attributes, func(a protocol.AttributeValue, _ int) legacyprotocol.Attribute {
-					return legacyprotocol.Attribute{
-						Name:  a.Name,
-						Value: a.Value,
-						Max:   a.Max,
-						Min:   a.Min,
-					}
-				}),
-				EntityLinks: lo.Map(pk.EntityLinks, func(l protocol.EntityLink, _ int) legacyprotocol.EntityLink {
-					return legacyprotocol.EntityLink{
-						Type:                 l.Type,
-						RiddenEntityUniqueID: l.RiddenEntityUniqueID,
-						RiderEntityUniqueID:  l.RiddenEntityUniqueID,
-						Immediate:            l.Immediate,
-					}
-				}),
+				Attributes:      downgradeAttributeValues(pk.Attributes),
+				EntityLinks:     lo.Map(pk.EntityLinks, downgradeEntityLink),
 			},
 		}
 	case *packet.AddPlayer:
+		s.linkVehicles(pk.EntityLinks, conn.GameData().EntityUniqueID)
 		return []packet.Packet{
 			&legacypacket.AddPlayer{
 				UUID:                   pk.UUID,
@@ -464,31 +547,24 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 				EntityUniqueID:         pk.AbilityData.EntityUniqueID,
 				EntityRuntimeID:        pk.EntityRuntimeID,
 				PlatformChatID:         pk.PlatformChatID,
-				Position:               pk.Position,
+				Position:               s.downgradePos(pk.Position),
 				Velocity:               pk.Velocity,
 				Pitch:                  pk.Pitch,
 				Yaw:                    pk.Yaw,
 				HeadYaw:                pk.HeadYaw,
-				HeldItem:               downgradeItem(pk.HeldItem.Stack),
-				EntityMetadata:         legacyprotocol.DowngradeEntityMetadata(pk.EntityMetadata),
+				HeldItem:               s.downgradeItem(pk.HeldItem.Stack),
+				EntityMetadata:         legacyprotocol.DowngradeEntityMetadata(pk.EntityMetadata, s.latestPalette()),
 				CommandPermissionLevel: uint32(pk.AbilityData.CommandPermissions),
 				PermissionLevel:        uint32(pk.AbilityData.PlayerPermissions),
 				DeviceID:               pk.DeviceID,
-				EntityLinks: lo.Map(pk.EntityLinks, func(l protocol.EntityLink, _ int) legacyprotocol.EntityLink {
-					return legacyprotocol.EntityLink{
-						Type:                 l.Type,
-						RiddenEntityUniqueID: l.RiddenEntityUniqueID,
-						RiderEntityUniqueID:  l.RiddenEntityUniqueID,
-						Immediate:            l.Immediate,
-					}
-				}),
+				EntityLinks:            lo.Map(pk.EntityLinks, downgradeEntityLink),
 			},
 		}
 	case *packet.MobEquipment:
 		return []packet.Packet{
 			&legacypacket.MobEquipment{
 				EntityRuntimeID: pk.EntityRuntimeID,
-				NewItem:         downgradeItem(pk.NewItem.Stack),
+				NewItem:         s.downgradeItem(pk.NewItem.Stack),
 				InventorySlot:   pk.InventorySlot,
 				HotBarSlot:      pk.HotBarSlot,
 				WindowID:        pk.WindowID,
@@ -498,10 +574,10 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 		return []packet.Packet{
 			&legacypacket.MobArmourEquipment{
 				EntityRuntimeID: pk.EntityRuntimeID,
-				Helmet:          downgradeItem(pk.Helmet.Stack),
-				Chestplate:      downgradeItem(pk.Chestplate.Stack),
-				Leggings:        downgradeItem(pk.Leggings.Stack),
-				Boots:           downgradeItem(pk.Boots.Stack),
+				Helmet:          s.downgradeItem(pk.Helmet.Stack),
+				Chestplate:      s.downgradeItem(pk.Chestplate.Stack),
+				Leggings:        s.downgradeItem(pk.Leggings.Stack),
+				Boots:           s.downgradeItem(pk.Boots.Stack),
 			},
 		}
 	case *packet.AddItemActor:
@@ -509,14 +585,18 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			&legacypacket.AddItemActor{
 				EntityUniqueID:  pk.EntityUniqueID,
 				EntityRuntimeID: pk.EntityRuntimeID,
-				Item:            downgradeItem(pk.Item.Stack),
-				Position:        pk.Position,
+				Item:            s.downgradeItem(pk.Item.Stack),
+				Position:        s.downgradePos(pk.Position),
 				Velocity:        pk.Velocity,
-				EntityMetadata:  legacyprotocol.DowngradeEntityMetadata(pk.EntityMetadata),
+				EntityMetadata:  legacyprotocol.DowngradeEntityMetadata(pk.EntityMetadata, s.latestPalette()),
 				FromFishing:     pk.FromFishing,
 			},
 		}
+	case *packet.ContainerOpen:
+		s.trackContainer(pk)
+		pk.ContainerPosition = s.downgradeBlockPos(pk.ContainerPosition)
 	case *packet.ContainerClose:
+		s.closeContainer()
 		return []packet.Packet{
 			&legacypacket.ContainerClose{
 				WindowID: pk.WindowID,
@@ -602,39 +682,44 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 		return []packet.Packet{
 			&legacypacket.UpdateAttributes{
 				EntityRuntimeID: pk.EntityRuntimeID,
-				Attributes: lo.Map(pk.Attributes, func(attribute protocol.Attribute, _ int) legacyprotocol.Attribute {
-					return legacyprotocol.Attribute{
-						Name:    attribute.Name,
-						Value:   attribute.Value,
-						Min:     attribute.Min,
-						Max:     attribute.Max,
-						Default: attribute.Default,
-					}
-				}),
+				Attributes:      downgradeAttributes(pk.Attributes),
 			},
 		}
+	case *packet.MobEffect:
+		if effect, ok := downgradeMobEffect(pk); ok {
+			return []packet.Packet{effect}
+		}
+		return nil
 	case *packet.SetActorData:
 		return []packet.Packet{
 			&legacypacket.SetActorData{
 				EntityRuntimeID: pk.EntityRuntimeID,
-				EntityMetadata:  legacyprotocol.DowngradeEntityMetadata(pk.EntityMetadata),
+				EntityMetadata:  legacyprotocol.DowngradeEntityMetadata(s.entityMetadata(pk.EntityRuntimeID, pk.EntityMetadata), s.latestPalette()),
 			},
 		}
+	case *packet.RemoveActor:
+		s.removeEntity(pk.EntityUniqueID)
+	case *packet.AvailableActorIdentifiers:
+		pk.SerialisedEntityIdentifiers = downgradeActorIdentifiers(pk.SerialisedEntityIdentifiers)
 	case *packet.InventorySlot:
+		item := s.downgradeItem(pk.NewItem.Stack)
+		s.trackSlot(pk.WindowID, pk.Slot, pk.NewItem, item)
 		return []packet.Packet{
 			&legacypacket.InventorySlot{
 				WindowID: pk.WindowID,
 				Slot:     pk.Slot,
-				NewItem:  downgradeItem(pk.NewItem.Stack),
+				NewItem:  item,
 			},
 		}
 	case *packet.InventoryContent:
+		content := lo.Map(pk.Content, func(instance protocol.ItemInstance, _ int) legacyprotocol.ItemStack {
+			return s.downgradeItem(instance.Stack)
+		})
+		s.trackContent(pk.WindowID, pk.Content, content)
 		return []packet.Packet{
 			&legacypacket.InventoryContent{
 				WindowID: pk.WindowID,
-				Content: lo.Map(pk.Content, func(instance protocol.ItemInstance, _ int) legacyprotocol.ItemStack {
-					return downgradeItem(instance.Stack)
-				}),
+				Content:  content,
 			},
 		}
 	case *packet.ResourcePacksInfo:
@@ -674,58 +759,45 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 			},
 		}
 	case *packet.LevelEvent:
-		if pk.EventType == packet.LevelEventParticlesDestroyBlock || pk.EventType == packet.LevelEventParticlesCrackBlock {
-			pk.EventData = int32(downgradeBlockRuntimeID(uint32(pk.EventData)))
+		if event, ok := s.downgradeLevelEvent(pk); ok {
+			return []packet.Packet{event}
 		}
+		return nil
 	case *packet.AvailableCommands:
 		return []packet.Packet{
 			&legacypacket.AvailableCommands{
-				Commands: lo.Map(pk.Commands, func(c protocol.Command, _ int) legacyprotocol.Command {
-					return legacyprotocol.Command{
-						Name:            c.Name,
-						Description:     c.Description,
-						Flags:           byte(c.Flags),
-						PermissionLevel: c.PermissionLevel,
-						//Aliases:         c.Aliases,
-						Overloads: lo.Map(c.Overloads, func(o protocol.CommandOverload, i int) legacyprotocol.CommandOverload {
-							return legacyprotocol.CommandOverload{Parameters: lo.Map(o.Parameters, func(p protocol.CommandParameter, _ int) legacyprotocol.CommandParameter {
-								return legacyprotocol.CommandParameter{
-									Name:                p.Name,
-									Type:                p.Type,
-									Optional:            p.Optional,
-									CollapseEnumOptions: true,
-									//Enum:                legacyprotocol.CommandEnum(p.Enum),
-									//Suffix:              p.Suffix,
-								}
-							})}
-						}),
-					}
-				}),
+				Commands: downgradeCommands(pk),
+			},
+		}
+	case *packet.CommandOutput:
+		return []packet.Packet{s.downgradeCommandOutput(pk)}
+	case *packet.ItemStackResponse:
+		// The legacy client predicts the results of its inventory transactions itself, so we only need to keep
+		// track of the new stack network IDs, and resend the slots changed by requests that were rejected.
+		return s.trackResponses(pk.Responses)
+	case *packet.CraftingData:
+		return []packet.Packet{
+			&legacypacket.CraftingData{
+				Recipes:      s.downgradeRecipes(pk.Recipes, pk.ClearRecipes),
+				ClearRecipes: pk.ClearRecipes,
 			},
 		}
+	case *packet.ItemRegistry:
+		// The client has no notion of custom items. Instead, they are shown as fallback items.
+		s.registerCustomItems(pk.Items)
+		return nil
 	case *packet.CreativeContent:
 		return []packet.Packet{
 			&legacypacket.InventoryContent{
-				WindowID: 121,
-				Content: lo.Map(pk.Items, func(instance protocol.CreativeItem, _ int) legacyprotocol.ItemStack {
-					return downgradeItem(instance.Item)
-				}),
+				WindowID: legacyprotocol.WindowIDCreative,
+				Content:  s.downgradeCreativeContent(pk.Items),
 			},
 		}
 	case *packet.LevelSoundEvent:
-		if pk.SoundType == 113 || pk.SoundType == 145 || pk.SoundType == 151 || pk.SoundType <= 198 && pk.SoundType >= 195 || pk.SoundType == 222 || pk.SoundType == 227 {
-			return nil
-		}
-		return []packet.Packet{
-			&legacypacket.LevelSoundEvent{
-				SoundType:             pk.SoundType,
-				Position:              pk.Position,
-				ExtraData:             pk.ExtraData,
-				EntityType:            pk.EntityType,
-				BabyMob:               pk.BabyMob,
-				DisableRelativeVolume: pk.DisableRelativeVolume,
-			},
+		if sound, ok := s.downgradeSound(pk); ok {
+			return []packet.Packet{sound}
 		}
+		return nil
 	case *packet.PlayerSkin:
 		var patch struct {
 			Geometry struct {
@@ -746,108 +818,224 @@ func (Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []pack
 				PremiumSkin:      pk.Skin.PremiumSkin,
 			},
 		}
+	case *packet.SetActorLink:
+		s.linkVehicle(pk.EntityLink, conn.GameData().EntityUniqueID)
+		return []packet.Packet{
+			&legacypacket.SetActorLink{
+				EntityLink: downgradeEntityLink(pk.EntityLink, 0),
+			},
+		}
 	case *packet.Animate:
-		if pk.ActionType > 4 { // TODO: This is also pretty fucking ugly
-			return []packet.Packet{}
+		if animate, ok := downgradeAnimate(pk); ok {
+			return []packet.Packet{animate}
+		}
+		return nil
+	case *packet.ActorEvent:
+		if event, ok := s.downgradeActorEvent(pk); ok {
+			return []packet.Packet{event}
 		}
+		return nil
 	}
 	return []packet.Packet{pk}
 }
 
-var (
-	// latestAirRID is the runtime ID of the air block in the latest version of the game.
-	latestAirRID, _ = latestmappings.StateToRuntimeID("minecraft:air", nil)
-	// legacyAirRID is the runtime ID of the air block in the v1.12.0 version.
-	legacyAirRID = legacymappings.StateToRuntimeID("minecraft:air", nil)
-)
+// downgradeEntityLink downgrades an entity link of the latest version to a legacy entity link.
+func downgradeEntityLink(l protocol.EntityLink, _ int) legacyprotocol.EntityLink {
+	return legacyprotocol.EntityLink{
+		RiddenEntityUniqueID: l.RiddenEntityUniqueID,
+		RiderEntityUniqueID:  l.RiderEntityUniqueID,
+		Type:                 l.Type,
+		Immediate:            l.Immediate,
+	}
+}
+
+// legacyAirRID is the runtime ID of the air block in the v1.12.0 version.
+var legacyAirRID = legacymappings.StateToRuntimeID("minecraft:air", nil)
+
+// downgradeItem downgrades the input item stack to a legacy item stack. Items that don't exist in v1.12.0 are
+// replaced with a fallback item.
+func (s *session) downgradeItem(input protocol.ItemStack) legacyprotocol.ItemStack {
+	item, _ := s.tryDowngradeItem(input)
+	return item
+}
 
-// downgradeItem downgrades the input item stack to a legacy item stack. It returns a boolean indicating if the item was
-// downgraded successfully.
-func downgradeItem(input protocol.ItemStack) legacyprotocol.ItemStack {
+// tryDowngradeItem downgrades the input item stack to a legacy item stack. It returns a boolean indicating if the
+// item was downgraded successfully. If not, the item returned is a fallback item.
+func (s *session) tryDowngradeItem(input protocol.ItemStack) (legacyprotocol.ItemStack, bool) {
+	if c, ok := s.customItem(input.NetworkID); ok {
+		return downgradeCustomItem(c, input), true
+	}
 	name, _ := latestmappings.ItemRuntimeIDToName(input.NetworkID)
-	networkID, _ := legacymappings.ItemIDByName(name)
+	networkID, metadataValue, ok := legacymappings.DowngradeItem(name, int16(input.MetadataValue))
+	fallback, found := itemFallback(name)
+	if found {
+		networkID, metadataValue, ok = fallback.id, fallback.meta, true
+	}
+	nbtData := input.NBTData
+	if damage, ok := nbtData["Damage"].(int32); ok && legacymappings.Damageable(networkID) {
+		// Damageable items stored their damage in the metadata value in v1.12.0.
+		metadataValue, nbtData = int16(damage), withoutKey(nbtData, "Damage")
+	}
+	nbtData = downgradeItemNBT(nbtData)
+	if found {
+		// The original item is kept in the NBT of the fallback, so that it can be restored when the client sends
+		// the item back.
+		nbtData = withFallbackTag(nbtData, input.ItemType)
+	}
 	return legacyprotocol.ItemStack{
 		ItemType: legacyprotocol.ItemType{
 			NetworkID:     int32(networkID),
-			MetadataValue: int16(input.MetadataValue),
+			MetadataValue: metadataValue,
 		},
 		Count:         int16(input.Count),
-		NBTData:       input.NBTData,
+		NBTData:       nbtData,
 		CanBePlacedOn: input.CanBePlacedOn,
 		CanBreak:      input.CanBreak,
-	}
+	}, ok
 }
 
 // upgradeItem upgrades the input item stack to the latest item stack. It returns a boolean indicating if the item was
 // upgraded successfully.
-func upgradeItem(input legacyprotocol.ItemStack) protocol.ItemStack {
+func (s *session) upgradeItem(input legacyprotocol.ItemStack) protocol.ItemStack {
 	if input.ItemType.NetworkID == 0 {
 		return protocol.ItemStack{}
 	}
-	name, _ := legacymappings.ItemNameByID(int16(input.ItemType.NetworkID))
+	if item, ok := s.upgradeCustomItem(input); ok {
+		return item
+	}
+	name, metadataValue, _ := legacymappings.UpgradeItem(int16(input.ItemType.NetworkID), input.ItemType.MetadataValue)
 	networkID, _ := latestmappings.ItemNameToRuntimeID(name)
+	nbtData := upgradeItemNBT(input.NBTData)
+	if metadataValue != 0 && metadataValue != legacymappings.MetadataWildcard && legacymappings.Damageable(int16(input.ItemType.NetworkID)) {
+		nbtData = withoutKey(nbtData, "Damage")
+		nbtData["Damage"], metadataValue = int32(metadataValue), 0
+	}
+	itemType := protocol.ItemType{NetworkID: networkID, MetadataValue: uint32(metadataValue)}
+	if original, ok := fallbackOriginal(nbtData); ok {
+		// The item is a user fallback, so the item it was shown in place of is restored.
+		itemType, nbtData = original, withoutKey(nbtData, fallbackItemTag)
+	}
 	return protocol.ItemStack{
-		ItemType: protocol.ItemType{
-			NetworkID:     networkID,
-			MetadataValue: uint32(input.ItemType.MetadataValue),
-		},
+		ItemType:      itemType,
 		Count:         uint16(input.Count),
-		NBTData:       input.NBTData,
+		NBTData:       nbtData,
 		CanBePlacedOn: input.CanBePlacedOn,
 		CanBreak:      input.CanBreak,
 	}
 }
 
-// downgradeBlockRuntimeID downgrades the latest block runtime ID to a v1.12.0 block runtime ID.
-func downgradeBlockRuntimeID(input uint32) uint32 {
-	name, properties, ok := latestmappings.RuntimeIDToState(input)
+// withoutKey returns a copy of the NBT data passed without the key passed. The NBT data passed is left untouched.
+func withoutKey(nbtData map[string]any, key string) map[string]any {
+	m := make(map[string]any, len(nbtData))
+	for k, v := range nbtData {
+		if k != key {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// downgradeBlock downgrades a block runtime ID of the server of the session to a v1.12.0 block runtime ID.
+func (s *session) downgradeBlock(input uint32) uint32 {
+	return downgradeBlockRuntimeID(input, s.latestPalette(), s.customBlockFallbacks())
+}
+
+// upgradeBlock upgrades a v1.12.0 block runtime ID to a block runtime ID of the server of the session.
+func (s *session) upgradeBlock(input uint32) uint32 {
+	return upgradeBlockRuntimeID(input, s.latestPalette())
+}
+
+// downgradeBlockRuntimeID downgrades a block runtime ID of the palette passed to a v1.12.0 block runtime ID. Custom
+// blocks are downgraded to the fallbacks passed, indexed by the name of the custom block, unless the user configured
+// a different fallback.
+func downgradeBlockRuntimeID(input uint32, palette *latestmappings.Palette, fallbacks map[string]uint32) uint32 {
+	name, properties, ok := palette.RuntimeIDToState(input)
 	if !ok {
 		return legacyAirRID
 	}
+	if rid, ok := blockFallbackFor(name, properties); ok {
+		return rid
+	}
+	if rid, ok := fallbacks[name]; ok {
+		return rid
+	}
 	return legacymappings.StateToRuntimeID(name, properties)
 }
 
-// upgradeBlockRuntimeID upgrades a v1.12.0 block runtime ID to the latest block runtime ID.
-func upgradeBlockRuntimeID(input uint32) uint32 {
+// upgradeBlockRuntimeID upgrades a v1.12.0 block runtime ID to a block runtime ID of the palette passed.
+func upgradeBlockRuntimeID(input uint32, palette *latestmappings.Palette) uint32 {
 	name, properties, ok := legacymappings.RuntimeIDToState(input)
 	if !ok {
-		return latestAirRID
+		return palette.AirRuntimeID()
 	}
-	runtimeID, ok := latestmappings.StateToRuntimeID(name, properties)
+	runtimeID, ok := palette.StateToRuntimeID(name, properties)
 	if !ok {
-		return latestAirRID
+		return palette.AirRuntimeID()
 	}
 	return runtimeID
 }
 
-// downgradeChunk downgrades a chunk from the latest version to the v1.12.0 equivalent.
-func downgradeChunk(chunk *chunk.Chunk) *legacychunk.Chunk {
+// downgradeBlockEntities reads all block entities from the buffer passed and writes the ones shown by v1.12.0 to
+// the writer passed, downgraded to the v1.12.0 NBT and shifted upwards by the Y offset passed. Both the block entities
+// read and the downgraded ones are returned.
+func downgradeBlockEntities(w io.Writer, buf *bytes.Buffer, yOffset int32) (blockEntities, legacyBlockEntities []map[string]any) {
+	dec, enc := nbt.NewDecoderWithEncoding(buf, nbt.NetworkLittleEndian), nbt.NewEncoderWithEncoding(w, nbt.NetworkLittleEndian)
+	for {
+		var blockEntity map[string]any
+		if err := dec.Decode(&blockEntity); err != nil {
+			return
+		}
+		blockEntities = append(blockEntities, blockEntity)
+		if legacyBlockEntity, ok := DowngradeBlockEntity(blockEntity); ok {
+			legacyBlockEntity = shiftBlockEntity(legacyBlockEntity, yOffset)
+			legacyBlockEntities = append(legacyBlockEntities, legacyBlockEntity)
+			_ = enc.Encode(legacyBlockEntity)
+		}
+	}
+}
+
+// downgradeChunk downgrades a chunk from the latest version to the v1.12.0 equivalent. Custom blocks and biomes
+// are downgraded to their fallbacks. The chunk is shifted upwards by the Y offset of the session, which decides
+// which 16 sub chunks of the latest chunk end up in the v1.12.0 chunk. The runtime IDs of the chunk are those of the
+// palette passed.
+func (s *session) downgradeChunk(chunk *chunk.Chunk, palette *latestmappings.Palette) *legacychunk.Chunk {
+	fallbacks, downgradeBiome := s.customBlockFallbacks(), s.biomeDowngrader()
+
 	// First downgrade the blocks.
 	downgraded := legacychunk.New(legacyAirRID)
-	for subInd, sub := range chunk.Sub()[4 : len(chunk.Sub())-4] {
+	first := int((maxYOffset - s.yOffset) >> 4)
+	for subInd, sub := range chunk.Sub()[first : first+len(downgraded.Sub())] {
 		for layerInd, layer := range sub.Layers() {
 			downgradedLayer := downgraded.Sub()[subInd].Layer(uint8(layerInd))
 			for x := uint8(0); x < 16; x++ {
 				for z := uint8(0); z < 16; z++ {
 					for y := uint8(0); y < 16; y++ {
 						latestRuntimeID := layer.At(x, y, z)
-						if latestRuntimeID == latestAirRID {
+						if latestRuntimeID == palette.AirRuntimeID() {
 							// Don't bother with air.
 							continue
 						}
 
-						downgradedLayer.SetRuntimeID(x, y, z, downgradeBlockRuntimeID(latestRuntimeID))
+						downgradedLayer.SetRuntimeID(x, y, z, downgradeBlockRuntimeID(latestRuntimeID, palette, fallbacks))
 					}
 				}
 			}
 		}
 	}
 
-	// Then downgrade the biomes.
+	// Then downgrade the biomes. The client only has 2D biomes, so the biome at the surface of every column is used,
+	// which is the one that decides the colour of the grass and water the player sees.
+	r := chunk.Range()
+	minY, maxY := int16(r[0]+first<<4), int16(r[0]+first<<4+255)
 	for x := uint8(0); x < 16; x++ {
 		for z := uint8(0); z < 16; z++ {
-			// Use the highest block as an estimate for the biome, since we only have 2D biomes.
-			downgraded.SetBiomeID(x, z, uint8(chunk.Biome(x, chunk.HighestBlock(x, z), z)))
+			y := chunk.HighestBlock(x, z)
+			if y <= int16(r[0]) {
+				// The column is empty, so use the biome at sea level instead.
+				y = 62
+			}
+			y = max(minY, min(maxY, y))
+			downgraded.SetBiomeID(x, z, downgradeBiome(chunk.Biome(x, y, z)))
 		}
 	}
 	return downgraded
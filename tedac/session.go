@@ -0,0 +1,115 @@
+package tedac
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol"
+)
+
+// session holds the state that the Protocol keeps for a single connection. Most conversions are stateless,
+// but some of them, such as the ones for crafting, depend on data the server sent earlier in the session.
+type session struct {
+	mu sync.Mutex
+
+	// recipes holds all recipes that were sent to the client, along with the network ID that the server
+	// assigned to them.
+	recipes []craftingRecipe
+	// creativeItems holds the items shown in the creative inventory of the client, in the order they were
+	// sent.
+	creativeItems []creativeItem
+	// craftingGrid holds the items that the client placed in the crafting grid, indexed by the slot in the
+	// grid.
+	craftingGrid map[uint32]legacyprotocol.ItemStack
+
+	// stackNetworkIDs holds the stack network IDs of the items the server sent, indexed by the window and
+	// slot they are in.
+	stackNetworkIDs map[windowSlot]int32
+	// items holds the items in the slots of the windows of the client as the server last sent or confirmed them.
+	items map[windowSlot]legacyprotocol.ItemStack
+	// requestID is the ID of the last item stack request sent to the server.
+	requestID int32
+	// pendingRequests holds the changes that the client predicted for the item stack requests that the server has
+	// not yet responded to, indexed by the ID of the request.
+	pendingRequests map[int32][]slotChange
+	// openWindow is the window ID of the container the client currently has opened, and openContainerType
+	// the type of that container.
+	openWindow, openContainerType byte
+
+	// blockFallbacks holds the runtime IDs of the legacy blocks shown in place of the custom blocks of the
+	// server, indexed by the name of the custom block. The map is replaced as a whole, never modified.
+	blockFallbacks map[string]uint32
+
+	// palette holds the block palette of the server if it has custom blocks. It is set once the StartGame packet
+	// is received.
+	palette atomic.Pointer[latestmappings.Palette]
+
+	// customBiomes holds the IDs of the v1.12.0 biomes shown in place of the custom biomes of the server, indexed
+	// by the ID of the custom biome. The map is replaced as a whole, never modified.
+	customBiomes map[uint32]uint8
+
+	// entityFallbacks holds the legacy entities shown in place of the entities spawned by the server that don't
+	// exist in v1.12.0, indexed by the runtime ID of the entity. entityRuntimeIDs maps the unique IDs of these
+	// entities to their runtime IDs.
+	entityFallbacks  map[uint64]legacymappings.EntityFallback
+	entityRuntimeIDs map[int64]uint64
+
+	// customItemsMu guards customItems. It is separate from mu, because items are converted while mu is held.
+	customItemsMu sync.RWMutex
+	// customItems holds the custom items of the server, indexed by their runtime ID.
+	customItems map[int32]customItem
+
+	// commandOrigins holds the origins of the commands the client requested that the server has not yet sent
+	// output for, indexed by the request ID the command was forwarded to the server with.
+	commandOrigins map[string]legacyprotocol.CommandOrigin
+
+	// vehicle holds the input that the client sent for the entity it is riding.
+	vehicle vehicleState
+
+	// removedFormElements holds the indices of the elements that were removed from the custom forms sent to the
+	// client, indexed by the ID of the form. pendingForms holds the IDs of these forms from oldest to newest.
+	removedFormElements map[uint32][]int
+	pendingForms        []uint32
+
+	// yOffset is the amount of blocks that the world is shifted upwards for the client. It is fixed for the
+	// duration of the session.
+	yOffset int32
+
+	// download holds the *WorldDownload that the chunks sent to the connection are written to, if any.
+	download atomic.Pointer[WorldDownload]
+}
+
+// newSession creates a new session for a connection that starts using the Protocol.
+func newSession() *session {
+	return &session{
+		craftingGrid:    make(map[uint32]legacyprotocol.ItemStack),
+		stackNetworkIDs: make(map[windowSlot]int32),
+		items:           make(map[windowSlot]legacyprotocol.ItemStack),
+		pendingRequests: make(map[int32][]slotChange),
+		requestID:       1,
+		yOffset:         yOffset.Load(),
+	}
+}
+
+// sessions holds the session of every connection currently using the Protocol, indexed by the
+// *minecraft.Conn.
+var sessions sync.Map
+
+// sessionFor returns the session of the connection passed. A new session is created if the connection did
+// not have one yet. It is removed again once the connection is closed.
+func sessionFor(conn *minecraft.Conn) *session {
+	if s, ok := sessions.Load(conn); ok {
+		return s.(*session)
+	}
+	s, loaded := sessions.LoadOrStore(conn, newSession())
+	if !loaded {
+		go func() {
+			<-conn.Context().Done()
+			sessions.Delete(conn)
+		}()
+	}
+	return s.(*session)
+}
@@ -0,0 +1,67 @@
+package tedac
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacymappings"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol/legacypacket"
+)
+
+// blockSounds holds the level sound events of the latest version of which the extra data is the runtime ID of the
+// block that the sound is played for.
+var blockSounds = map[uint32]struct{}{
+	packet.SoundEventItemUseOn: {},
+	packet.SoundEventHit:       {},
+	packet.SoundEventStep:      {},
+	packet.SoundEventBreak:     {},
+	packet.SoundEventPlace:     {},
+	packet.SoundEventHeavyStep: {},
+	packet.SoundEventFall:      {},
+	packet.SoundEventLand:      {},
+}
+
+// downgradeSound downgrades a level sound event of the latest version to v1.12.0. Sounds added after v1.12.0 are
+// replaced with a similar sound, and false is returned if there is none.
+func (s *session) downgradeSound(pk *packet.LevelSoundEvent) (*legacypacket.LevelSoundEvent, bool) {
+	soundType, ok := legacymappings.DowngradeSound(pk.SoundType)
+	if !ok {
+		return nil, false
+	}
+	extraData := pk.ExtraData
+	// blockSounds is indexed by the sound IDs of the latest version, so the sound is looked up before it is mapped.
+	if _, ok := blockSounds[pk.SoundType]; ok && extraData >= 0 {
+		extraData = int32(s.downgradeBlock(uint32(extraData)))
+	}
+	entityType := pk.EntityType
+	if entityType != "" {
+		entityType = downgradeEntityType(entityType).Type
+	}
+	return &legacypacket.LevelSoundEvent{
+		SoundType:             soundType,
+		Position:              s.downgradePos(pk.Position),
+		ExtraData:             extraData,
+		EntityType:            entityType,
+		BabyMob:               pk.BabyMob,
+		DisableRelativeVolume: pk.DisableRelativeVolume,
+	}, true
+}
+
+// upgradeSound upgrades a level sound event sent by a v1.12.0 client to the latest version. False is returned if
+// the sound is not known.
+func (s *session) upgradeSound(pk *legacypacket.LevelSoundEvent) (*packet.LevelSoundEvent, bool) {
+	soundType, ok := legacymappings.UpgradeSound(pk.SoundType)
+	if !ok {
+		return nil, false
+	}
+	extraData := pk.ExtraData
+	if _, ok := blockSounds[soundType]; ok && extraData >= 0 {
+		extraData = int32(s.upgradeBlock(uint32(extraData)))
+	}
+	return &packet.LevelSoundEvent{
+		SoundType:             soundType,
+		Position:              s.upgradePos(pk.Position),
+		ExtraData:             extraData,
+		EntityType:            pk.EntityType,
+		BabyMob:               pk.BabyMob,
+		DisableRelativeVolume: pk.DisableRelativeVolume,
+	}, true
+}
@@ -0,0 +1,24 @@
+package tedac
+
+import "strings"
+
+// formattingReplacer replaces the material colour formatting codes that were added after v1.12.0 with the
+// closest colour that v1.12.0 supports.
+var formattingReplacer = strings.NewReplacer(
+	"§h", "§f", // Quartz.
+	"§i", "§7", // Iron.
+	"§j", "§8", // Netherite.
+	"§m", "§4", // Redstone.
+	"§n", "§6", // Copper.
+	"§p", "§6", // Gold.
+	"§q", "§2", // Emerald.
+	"§s", "§b", // Diamond.
+	"§t", "§1", // Lapis.
+	"§u", "§5", // Amethyst.
+	"§v", "§6", // Resin.
+)
+
+// downgradeText downgrades text shown to the client, replacing formatting codes unknown to v1.12.0.
+func downgradeText(s string) string {
+	return formattingReplacer.Replace(s)
+}
@@ -0,0 +1,119 @@
+package tedac
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/tedacmc/tedac/tedac/legacyprotocol/legacypacket"
+)
+
+// vehicleState holds the input that the client sent for the entity it is riding. The legacy client sends this
+// input in separate packets, whereas the latest version sends it in every PlayerAuthInput packet.
+type vehicleState struct {
+	// uniqueID is the unique ID of the entity that the player is riding, which is zero if not riding anything.
+	uniqueID int64
+	// rotation is the pitch and yaw of the player the last time it moved while riding.
+	rotation mgl32.Vec2
+	// movement is the movement vector of the last PlayerInput packet.
+	movement mgl32.Vec2
+	// jumping is true for as long as the client holds down jump while riding. startedJumping is set once it
+	// starts holding it down, until the input is next sent to the server.
+	jumping, startedJumping bool
+	// paddlingLeft and paddlingRight are set when the client paddles, until the input is next sent to the server.
+	paddlingLeft, paddlingRight bool
+}
+
+// linkVehicle updates the entity that the player is riding if the entity link passed has the player as its rider.
+func (s *session) linkVehicle(link protocol.EntityLink, playerUniqueID int64) {
+	if link.RiderEntityUniqueID != playerUniqueID {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if link.Type == protocol.EntityLinkRemove {
+		s.vehicle = vehicleState{}
+		return
+	}
+	s.vehicle.uniqueID = link.RiddenEntityUniqueID
+}
+
+// linkVehicles updates the entity that the player is riding from the entity links sent when spawning an entity, so
+// that a player that is spawned already riding the entity is tracked as well.
+func (s *session) linkVehicles(links []protocol.EntityLink, playerUniqueID int64) {
+	for _, link := range links {
+		s.linkVehicle(link, playerUniqueID)
+	}
+}
+
+// moveVehicle records the rotation of a legacy MovePlayer packet if the client sent it while riding an entity.
+func (s *session) moveVehicle(pk *legacypacket.MovePlayer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.vehicle.uniqueID != 0 && pk.RiddenEntityRuntimeID != 0 {
+		s.vehicle.rotation = mgl32.Vec2{pk.Pitch, pk.Yaw}
+	}
+}
+
+// vehicleInput records the movement and jumping of a legacy PlayerInput packet.
+func (s *session) vehicleInput(pk *legacypacket.PlayerInput) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vehicle.movement = pk.Movement
+	if pk.Jumping && !s.vehicle.jumping {
+		s.vehicle.startedJumping = true
+	}
+	s.vehicle.jumping = pk.Jumping
+}
+
+// paddle records the paddling of a legacy Animate packet. False is returned if the action passed is not a rowing
+// action.
+func (s *session) paddle(actionType int32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch actionType {
+	case packet.AnimateActionRowLeft:
+		s.vehicle.paddlingLeft = true
+	case packet.AnimateActionRowRight:
+		s.vehicle.paddlingRight = true
+	default:
+		return false
+	}
+	return true
+}
+
+// VehicleInput fills the vehicle fields of the PlayerAuthInput packet passed with the input that the client of the
+// connection passed sent for the entity it is riding. The packet is left unchanged if the connection does not use
+// the Protocol or if the player is not riding anything.
+func VehicleInput(conn *minecraft.Conn, pk *packet.PlayerAuthInput) {
+	value, ok := sessions.Load(conn)
+	if !ok {
+		return
+	}
+	s := value.(*session)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := &s.vehicle
+	if v.uniqueID == 0 {
+		return
+	}
+	pk.InputData.Set(packet.InputFlagClientPredictedVehicle)
+	pk.ClientPredictedVehicle, pk.VehicleRotation = v.uniqueID, v.rotation
+	pk.MoveVector = v.movement
+	if v.paddlingLeft {
+		pk.InputData.Set(packet.InputFlagPaddlingLeft)
+	}
+	if v.paddlingRight {
+		pk.InputData.Set(packet.InputFlagPaddlingRight)
+	}
+	if v.startedJumping {
+		pk.InputData.Set(packet.InputFlagStartJumping)
+	}
+	if v.jumping {
+		// Horses charge their jump for as long as jump is held down.
+		pk.InputData.Set(packet.InputFlagJumpDown)
+		pk.InputData.Set(packet.InputFlagJumping)
+	}
+	v.paddlingLeft, v.paddlingRight, v.startedJumping = false, false, false
+}
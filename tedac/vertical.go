@@ -0,0 +1,62 @@
+package tedac
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// maxYOffset is the largest Y offset that the world may be shifted by. The latest version has four sub chunks
+// below and above the 256 blocks that v1.12.0 clients are able to see.
+const maxYOffset = 64
+
+// yOffset holds the Y offset that the world is shifted by for v1.12.0 clients that join.
+var yOffset atomic.Int32
+
+// SetYOffset sets the amount of blocks that the world is shifted upwards for v1.12.0 clients that join afterwards.
+// These clients only see blocks with a Y between 0 and 255, so shifting the world makes a different part of it
+// visible: An offset of 64 shows the blocks from Y -64 to 191, while an offset of -64 shows the blocks from Y 64
+// to 319. The offset must be a multiple of 16 between -64 and 64. It is 0 by default.
+func SetYOffset(offset int32) error {
+	if offset%16 != 0 || offset < -maxYOffset || offset > maxYOffset {
+		return fmt.Errorf("invalid Y offset %v: must be a multiple of 16 between %v and %v", offset, -maxYOffset, maxYOffset)
+	}
+	yOffset.Store(offset)
+	return nil
+}
+
+// downgradePos shifts a position in the world of the latest version to the position that the client sees.
+func (s *session) downgradePos(pos mgl32.Vec3) mgl32.Vec3 {
+	return pos.Add(mgl32.Vec3{0, float32(s.yOffset)})
+}
+
+// upgradePos shifts a position sent by the client back to the position in the world of the latest version.
+func (s *session) upgradePos(pos mgl32.Vec3) mgl32.Vec3 {
+	return pos.Sub(mgl32.Vec3{0, float32(s.yOffset)})
+}
+
+// downgradeBlockPos shifts a block position in the world of the latest version to the position that the client
+// sees.
+func (s *session) downgradeBlockPos(pos protocol.BlockPos) protocol.BlockPos {
+	return protocol.BlockPos{pos[0], pos[1] + s.yOffset, pos[2]}
+}
+
+// upgradeBlockPos shifts a block position sent by the client back to the position in the world of the latest
+// version.
+func (s *session) upgradeBlockPos(pos protocol.BlockPos) protocol.BlockPos {
+	return protocol.BlockPos{pos[0], pos[1] - s.yOffset, pos[2]}
+}
+
+// shiftBlockEntity returns a copy of the block entity passed with its Y coordinate shifted by the amount of blocks
+// passed. The block entity passed is left untouched.
+func shiftBlockEntity(blockEntity map[string]any, dy int32) map[string]any {
+	y, ok := blockEntity["y"].(int32)
+	if dy == 0 || !ok {
+		return blockEntity
+	}
+	shifted := withoutKey(blockEntity, "y")
+	shifted["y"] = y + dy
+	return shifted
+}
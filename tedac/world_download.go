@@ -0,0 +1,272 @@
+package tedac
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	dfchunk "github.com/df-mc/dragonfly/server/world/chunk"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/tedacmc/tedac/tedac/chunk"
+	"github.com/tedacmc/tedac/tedac/latestmappings"
+	"github.com/tedacmc/tedac/tedac/legacychunk"
+)
+
+// WorldDownload writes the chunks and block entities that players were sent to a world on disk, so that the world
+// may be opened in single player later on. A WorldDownload is created using NewWorldDownload or
+// NewLegacyWorldDownload and is used for a connection by calling DownloadWorld.
+type WorldDownload struct {
+	mu    sync.Mutex
+	store worldStore
+	// spawn is the position that the world is saved with as spawn. It is set to the position of the first player
+	// that downloads the world.
+	spawn    mgl32.Vec3
+	spawnSet bool
+}
+
+// worldStore is a world format that chunks may be written to.
+type worldStore interface {
+	// storeChunk stores a chunk, both in the latest and the v1.12.0 format, along with the block entities in it.
+	storeChunk(pos protocol.ChunkPos, c *chunk.Chunk, palette *latestmappings.Palette, legacy *legacychunk.Chunk, blockEntities, legacyBlockEntities []map[string]any) error
+	// close saves the world with the spawn position passed and closes it.
+	close(spawn mgl32.Vec3) error
+}
+
+// NewWorldDownload creates a WorldDownload that writes a world in the format of the latest version to the
+// directory passed.
+func NewWorldDownload(dir string) (*WorldDownload, error) {
+	db, err := mcdb.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open world: %w", err)
+	}
+	return &WorldDownload{store: &latestWorld{db: db, runtimeIDs: make(map[paletteRuntimeID]uint32)}}, nil
+}
+
+// NewLegacyWorldDownload creates a WorldDownload that writes a world in the format of v1.12.0 to the directory
+// passed, so that it may be opened by v1.12.0 clients.
+func NewLegacyWorldDownload(dir string) (*WorldDownload, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "db"), 0777); err != nil {
+		return nil, fmt.Errorf("open world: %w", err)
+	}
+	db, err := leveldb.OpenFile(filepath.Join(dir, "db"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("open world: %w", err)
+	}
+	return &WorldDownload{store: &legacyWorld{db: db, dir: dir}}, nil
+}
+
+// DownloadWorld starts writing the chunks sent to the connection passed to the WorldDownload passed. Passing a nil
+// WorldDownload stops downloading the world for the connection.
+func DownloadWorld(conn *minecraft.Conn, d *WorldDownload) {
+	if d != nil {
+		d.mu.Lock()
+		if !d.spawnSet {
+			d.spawn, d.spawnSet = conn.GameData().PlayerPosition, true
+		}
+		d.mu.Unlock()
+	}
+	sessionFor(conn).download.Store(d)
+}
+
+// Close saves the world and closes the WorldDownload. Chunks sent after closing are no longer written.
+func (d *WorldDownload) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.store == nil {
+		return nil
+	}
+	err := d.store.close(d.spawn)
+	d.store = nil
+	return err
+}
+
+// storeChunk writes a chunk sent to a player to the world.
+func (d *WorldDownload) storeChunk(pos protocol.ChunkPos, c *chunk.Chunk, palette *latestmappings.Palette, legacy *legacychunk.Chunk, blockEntities, legacyBlockEntities []map[string]any) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.store == nil {
+		return nil
+	}
+	return d.store.storeChunk(pos, c, palette, legacy, blockEntities, legacyBlockEntities)
+}
+
+// latestWorld is a worldStore that writes worlds in the format of the latest version using dragonfly.
+type latestWorld struct {
+	db *mcdb.DB
+	// runtimeIDs maps the runtime ID of a block in the latest version to the runtime ID of the same block in
+	// dragonfly. Players on different servers may download to the same world, so runtime IDs are cached per
+	// palette.
+	runtimeIDs map[paletteRuntimeID]uint32
+}
+
+// storeChunk ...
+func (w *latestWorld) storeChunk(pos protocol.ChunkPos, c *chunk.Chunk, palette *latestmappings.Palette, _ *legacychunk.Chunk, blockEntities, _ []map[string]any) error {
+	r := c.Range()
+	converted := dfchunk.New(world.BlockRuntimeID(nil), r)
+	for subInd, sub := range c.Sub() {
+		baseY := int16(r[0]) + int16(subInd<<4)
+		for layerInd, layer := range sub.Layers() {
+			for x := uint8(0); x < 16; x++ {
+				for z := uint8(0); z < 16; z++ {
+					for y := uint8(0); y < 16; y++ {
+						latestRuntimeID := layer.At(x, y, z)
+						if latestRuntimeID == palette.AirRuntimeID() {
+							continue
+						}
+						converted.SetBlock(x, baseY+int16(y), z, uint8(layerInd), w.runtimeID(palette, latestRuntimeID))
+					}
+				}
+			}
+		}
+	}
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			for y := int16(r[0]); y <= int16(r[1]); y++ {
+				converted.SetBiome(x, y, z, c.Biome(x, y, z))
+			}
+		}
+	}
+
+	col := &dfchunk.Column{Chunk: converted}
+	for _, blockEntity := range blockEntities {
+		col.BlockEntities = append(col.BlockEntities, dfchunk.BlockEntity{Pos: blockEntityPos(blockEntity), Data: blockEntity})
+	}
+	return w.db.StoreColumn(world.ChunkPos{pos[0], pos[1]}, world.Overworld, col)
+}
+
+// paletteRuntimeID is a runtime ID of a block in a specific palette.
+type paletteRuntimeID struct {
+	palette   *latestmappings.Palette
+	runtimeID uint32
+}
+
+// runtimeID returns the dragonfly runtime ID of the block with the runtime ID passed in the palette passed. Blocks
+// that dragonfly does not know about, such as custom blocks, are stored as air.
+func (w *latestWorld) runtimeID(palette *latestmappings.Palette, latestRuntimeID uint32) uint32 {
+	key := paletteRuntimeID{palette: palette, runtimeID: latestRuntimeID}
+	if rid, ok := w.runtimeIDs[key]; ok {
+		return rid
+	}
+	var b world.Block
+	if name, properties, ok := palette.RuntimeIDToState(latestRuntimeID); ok {
+		b, _ = world.BlockByName(name, properties)
+	}
+	rid := world.BlockRuntimeID(b)
+	w.runtimeIDs[key] = rid
+	return rid
+}
+
+// close ...
+func (w *latestWorld) close(spawn mgl32.Vec3) error {
+	s := w.db.Settings()
+	s.Name = "Tedac Download"
+	s.Spawn = cube.Pos{int(spawn.X()), int(spawn.Y()), int(spawn.Z())}
+	s.DefaultGameMode = world.GameModeCreative
+	w.db.SaveSettings(s)
+	return w.db.Close()
+}
+
+const (
+	// legacyChunkVersion is the version of the chunks written by v1.12.0.
+	legacyChunkVersion = 15
+	// legacyStorageVersion is the version of the level.dat written by v1.12.0.
+	legacyStorageVersion = 8
+
+	keyData2D        = '-'
+	keySubChunk      = '/'
+	keyBlockEntities = '1'
+	keyVersion       = 'v'
+)
+
+// legacyWorld is a worldStore that writes worlds in the format of v1.12.0.
+type legacyWorld struct {
+	db  *leveldb.DB
+	dir string
+}
+
+// storeChunk ...
+func (w *legacyWorld) storeChunk(pos protocol.ChunkPos, _ *chunk.Chunk, _ *latestmappings.Palette, c *legacychunk.Chunk, _, blockEntities []map[string]any) error {
+	index := make([]byte, 8)
+	binary.LittleEndian.PutUint32(index, uint32(pos[0]))
+	binary.LittleEndian.PutUint32(index[4:], uint32(pos[1]))
+	key := func(p ...byte) []byte {
+		return append(append([]byte(nil), index...), p...)
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Put(key(keyVersion), []byte{legacyChunkVersion})
+
+	data := legacychunk.Encode(c, legacychunk.DiskEncoding)
+	for y, sub := range c.Sub() {
+		if sub.Empty() {
+			batch.Delete(key(keySubChunk, byte(y)))
+			continue
+		}
+		batch.Put(key(keySubChunk, byte(y)), data.SubChunks[y])
+	}
+	batch.Put(key(keyData2D), data.Data2D)
+
+	if len(blockEntities) == 0 {
+		batch.Delete(key(keyBlockEntities))
+	} else {
+		buf := bytes.NewBuffer(nil)
+		enc := nbt.NewEncoderWithEncoding(buf, nbt.LittleEndian)
+		for _, blockEntity := range blockEntities {
+			if err := enc.Encode(blockEntity); err != nil {
+				return fmt.Errorf("encode block entity: %w", err)
+			}
+		}
+		batch.Put(key(keyBlockEntities), buf.Bytes())
+	}
+	return w.db.Write(batch, nil)
+}
+
+// close ...
+func (w *legacyWorld) close(spawn mgl32.Vec3) error {
+	levelDat, err := nbt.MarshalEncoding(map[string]any{
+		"LevelName":             "Tedac Download",
+		"StorageVersion":        int32(legacyStorageVersion),
+		"NetworkVersion":        Protocol{}.ID(),
+		"lastOpenedWithVersion": []int32{1, 12, 0, 0, 0},
+		"Generator":             int32(1),
+		"GameType":              int32(1),
+		"Difficulty":            int32(2),
+		"commandsEnabled":       uint8(1),
+		"SpawnX":                int32(spawn.X()),
+		"SpawnY":                int32(spawn.Y()),
+		"SpawnZ":                int32(spawn.Z()),
+		"LastPlayed":            time.Now().Unix(),
+	}, nbt.LittleEndian)
+	if err != nil {
+		return fmt.Errorf("encode level.dat: %w", err)
+	}
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint32(header, legacyStorageVersion)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(levelDat)))
+	if err := os.WriteFile(filepath.Join(w.dir, "level.dat"), append(header, levelDat...), 0644); err != nil {
+		return fmt.Errorf("write level.dat: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, "levelname.txt"), []byte("Tedac Download"), 0644); err != nil {
+		return fmt.Errorf("write levelname.txt: %w", err)
+	}
+	return w.db.Close()
+}
+
+// blockEntityPos returns the position of the block entity passed.
+func blockEntityPos(blockEntity map[string]any) cube.Pos {
+	x, _ := blockEntity["x"].(int32)
+	y, _ := blockEntity["y"].(int32)
+	z, _ := blockEntity["z"].(int32)
+	return cube.Pos{int(x), int(y), int(z)}
+}